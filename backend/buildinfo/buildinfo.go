@@ -0,0 +1,54 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// ビルド時に -ldflags "-X backend/buildinfo.Version=..." で埋め込まれる
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+type Info struct {
+	Version   string `json:"version" example:"v1.2.0" doc:"Build version"`
+	Commit    string `json:"commit" example:"80f5b6e" doc:"Git commit of the build"`
+	Date      string `json:"date" example:"2026-10-19T00:00:00Z" doc:"Build date"`
+	GoVersion string `json:"go_version" example:"go1.24.0" doc:"Go version used for the build"`
+}
+
+// Get はビルド情報を返す
+// ldflagsで埋め込まれていない値はGoが記録したVCSの情報で補う
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = s.Value
+				}
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.Date == "" {
+		info.Date = "unknown"
+	}
+	return info
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"backend/domain"
+	"backend/handler"
+	"context"
+	"io"
+)
+
+func runCat(app *App, ctx context.Context, args []string) error {
+	fs := app.newFlagSet("cat")
+	kind := fs.String("kind", domain.LocalRepoKind.String(), "kind of document source")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return ErrUsage
+	}
+
+	provider, err := findProvider[handler.DocumentContentProvider](app, *kind, handler.CapabilityRead)
+	if err != nil {
+		return err
+	}
+	content, err := provider.GetDocumentContent(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(app.Stdout, content)
+	return err
+}
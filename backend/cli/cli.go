@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"backend/config"
+	"backend/handler"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+)
+
+type App struct {
+	ConfigProvider config.AppConfigProvider
+	// Providers はCLIのコマンドが使用するプロバイダー（HTTPのハンドラーと同じものを使用する）
+	Providers *handler.ProviderRegistry
+	// Serve はサーバーを起動する（serveコマンド）
+	Serve  func(ctx context.Context) error
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// ErrUsage はコマンドの使い方が誤っている場合のエラー
+var ErrUsage = errors.New("invalid usage")
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(app *App, ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"serve", "serve", "Start the HTTP server (default)", func(app *App, ctx context.Context, args []string) error {
+		return app.Serve(ctx)
+	}},
+	{"ls", "ls [--kind local] [--json] <dir>", "List files in a directory", runLs},
+	{"cat", "cat [--kind local] <doc>", "Print the content of a document", runCat},
+	{"search", "search [--kind local] [--dir <dir>] [--json] <query>", "Search documents of the workspace", runSearch},
+	{"lint", "lint [--kind local] [--dir <dir>] [--json] [--fix] [--strict]", "Lint Markdown documents of the workspace", runLint},
+	{"config", "config get [key] | config set <key> <value>...", "Show or update the configuration", runConfig},
+	{"export", "export [--kind local] [--dir <dir>] [-o <file.zip>]", "Export documents of the workspace as a zip archive", runExport},
+}
+
+// Run は引数に応じてサブコマンドを実行する
+// サブコマンドが指定されていない場合はサーバーを起動する
+func (app *App) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return app.Serve(ctx)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			err := cmd.run(app, ctx, args[1:])
+			if errors.Is(err, ErrUsage) || errors.Is(err, flag.ErrHelp) {
+				fmt.Fprintln(app.Stderr, "usage: repo-wise", cmd.usage)
+			}
+			return err
+		}
+	}
+
+	if args[0] != "help" && args[0] != "-h" && args[0] != "--help" {
+		fmt.Fprintf(app.Stderr, "unknown command: %s\n", args[0])
+	}
+	app.printUsage()
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return nil
+	}
+	return ErrUsage
+}
+
+func (app *App) printUsage() {
+	fmt.Fprintln(app.Stderr, "usage: repo-wise <command> [arguments]")
+	fmt.Fprintln(app.Stderr)
+	fmt.Fprintln(app.Stderr, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(app.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+func (app *App) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(app.Stderr)
+	return fs
+}
+
+func (app *App) printJSON(v any) error {
+	encoder := json.NewEncoder(app.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// findProvider はkindに対応するプロバイダーを返す
+func findProvider[P any](app *App, value string, capability handler.Capability) (P, error) {
+	p, _, err := handler.FindProvider[P](app.Providers, value, capability)
+	return p, err
+}
+
+// searchRoots は--dirが指定されていればそのディレクトリ、無ければ現在のワークスペースのディレクトリを返す
+func searchRoots(appConfig *config.AppConfig, dir string) ([]string, error) {
+	if dir != "" {
+		return []string{dir}, nil
+	}
+	if len(appConfig.LocalFile.Directories) == 0 {
+		return nil, errors.New("no directories in the active workspace; pass --dir or run `repo-wise config set directories <dir>...`")
+	}
+	return appConfig.LocalFile.Directories, nil
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"backend/config"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// configKey はconfig get/setで扱える設定項目
+type configKey struct {
+	get func(appConfig *config.AppConfig) any
+	set func(appConfig *config.AppConfig, values []string) error
+}
+
+var configKeys = map[string]configKey{
+	"directories": {
+		get: func(c *config.AppConfig) any { return c.LocalFile.Directories },
+		set: func(c *config.AppConfig, values []string) error {
+			c.LocalFile.Directories = values
+			return nil
+		},
+	},
+	"github.ignore_repos": {
+		get: func(c *config.AppConfig) any { return c.Github.IgnoreRepos },
+		set: func(c *config.AppConfig, values []string) error {
+			c.Github.IgnoreRepos = values
+			return nil
+		},
+	},
+	"github.access_token": {
+		get: func(c *config.AppConfig) any { return c.Github.AccessToken.String() },
+		set: func(c *config.AppConfig, values []string) error {
+			if len(values) != 1 {
+				return ErrUsage
+			}
+			c.Github.AccessToken = config.Secret(values[0])
+			return nil
+		},
+	},
+	"active_workspace": {
+		get: func(c *config.AppConfig) any { return c.ActiveWorkspaceName },
+		set: func(c *config.AppConfig, values []string) error {
+			if len(values) != 1 {
+				return ErrUsage
+			}
+			if c.FindWorkspace(values[0]) < 0 {
+				return fmt.Errorf("workspace does not exist: %s", values[0])
+			}
+			c.ActiveWorkspaceName = values[0]
+			c.ApplyActiveWorkspace()
+			return nil
+		},
+	},
+}
+
+func configKeyNames() string {
+	names := make([]string, 0, len(configKeys))
+	for name := range configKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func runConfig(app *App, ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+
+	appConfig, err := app.ConfigProvider.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "get":
+		if len(args) == 1 {
+			return app.printJSON(appConfig.Redacted())
+		}
+		key, ok := configKeys[args[1]]
+		if !ok {
+			return fmt.Errorf("unknown config key: %s (available: %s)", args[1], configKeyNames())
+		}
+		return app.printJSON(key.get(appConfig))
+
+	case "set":
+		if len(args) < 2 {
+			return ErrUsage
+		}
+		key, ok := configKeys[args[1]]
+		if !ok {
+			return fmt.Errorf("unknown config key: %s (available: %s)", args[1], configKeyNames())
+		}
+
+		values := args[2:]
+		// 秘密情報をシェルの履歴に残さないように "-" の場合は標準入力から読む
+		if slices.Equal(values, []string{"-"}) {
+			line, err := bufio.NewReader(app.Stdin).ReadString('\n')
+			if err != nil && line == "" {
+				return errors.New("failed to read value from stdin")
+			}
+			values = []string{strings.TrimSpace(line)}
+		}
+
+		updated := *appConfig
+		updated.Workspaces = slices.Clone(appConfig.Workspaces)
+		if err := key.set(&updated, values); err != nil {
+			return err
+		}
+		return app.ConfigProvider.Save(ctx, &updated)
+
+	default:
+		return ErrUsage
+	}
+}
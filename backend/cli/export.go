@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"archive/zip"
+	"backend/domain"
+	"backend/handler"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// runExport はワークスペースのドキュメントをzipに書き出す
+// zip内のパスは <ルートディレクトリ名>/<ルートからの相対パス> になる
+// 同じ名前のルートディレクトリが複数ある場合は2つ目以降の名前に -2、-3 を付ける
+func runExport(app *App, ctx context.Context, args []string) (err error) {
+	fs := app.newFlagSet("export")
+	kind := fs.String("kind", domain.LocalRepoKind.String(), "kind of document source")
+	dir := fs.String("dir", "", "directory to export (default: directories of the active workspace)")
+	output := fs.String("o", "", "output file (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return ErrUsage
+	}
+
+	appConfig, err := app.ConfigProvider.Load(ctx)
+	if err != nil {
+		return err
+	}
+	roots, err := searchRoots(appConfig, *dir)
+	if err != nil {
+		return err
+	}
+	documentsProvider, err := findProvider[handler.DocumentsProvider](app, *kind, handler.CapabilityList)
+	if err != nil {
+		return err
+	}
+	contentProvider, err := findProvider[handler.DocumentContentProvider](app, *kind, handler.CapabilityRead)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = app.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = file
+	}
+
+	names := exportRootNames(roots)
+	archive := zip.NewWriter(w)
+	count := 0
+	for i, root := range roots {
+		docs, err := documentsProvider.GetDocuments(ctx, root, handler.DocumentConditionFor(appConfig))
+		if err != nil {
+			return err
+		}
+
+		for _, doc := range docs {
+			content, err := contentProvider.GetDocumentContent(ctx, doc.Path)
+			if err != nil {
+				return err
+			}
+			entry, err := archive.Create(filepath.ToSlash(filepath.Join(names[i], doc.Name)))
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(entry, content); err != nil {
+				return err
+			}
+			count++
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.Stderr, "exported %d documents\n", count)
+	return nil
+}
+
+// exportRootNames はルートディレクトリごとに重複しないzip内の名前を返す
+func exportRootNames(roots []string) []string {
+	names := make([]string, len(roots))
+	used := map[string]bool{}
+	for i, root := range roots {
+		base := filepath.Base(root)
+		name := base
+		for n := 2; used[name]; n++ {
+			name = base + "-" + strconv.Itoa(n)
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"archive/zip"
+	"backend/config"
+	"backend/config/configtest"
+	"backend/domain"
+	"backend/handler"
+	"backend/infra/filesystem"
+	"backend/infra/provider/local"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// newTestApp はdirectoriesを現在のワークスペースとし、ローカルのプロバイダーを使うAppを返す
+func newTestApp(t *testing.T, directories ...string) (*App, *bytes.Buffer) {
+	t.Helper()
+	appConfig := &config.AppConfig{AppMode: config.CLI}
+	appConfig.LocalFile.Directories = directories
+	localProvider, err := local.NewLocalProvider(filesystem.OS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := handler.NewProviderRegistry()
+	if err := registry.Register(domain.LocalRepoKind, localProvider); err != nil {
+		t.Fatal(err)
+	}
+	stdout := &bytes.Buffer{}
+	return &App{
+		ConfigProvider: configtest.NewProvider(appConfig),
+		Providers:      registry,
+		Stdout:         stdout,
+		Stderr:         io.Discard,
+	}, stdout
+}
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportKeepsRootsWithTheSameName(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "a", "docs"), filepath.Join(dir, "b", "docs")
+	writeTestFile(t, filepath.Join(first, "readme.md"), "first")
+	writeTestFile(t, filepath.Join(second, "readme.md"), "second")
+	writeTestFile(t, filepath.Join(second, "guide", "setup.md"), "setup")
+
+	app, stdout := newTestApp(t, first, second)
+	if err := app.Run(context.Background(), []string{"export"}); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(stdout.Bytes()), int64(stdout.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(r)
+		r.Close()
+		got[f.Name] = string(b)
+	}
+	want := map[string]string{
+		"docs/readme.md":        "first",
+		"docs-2/readme.md":      "second",
+		"docs-2/guide/setup.md": "setup",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("entries = %v, want %v", got, want)
+	}
+	for name, content := range want {
+		if got[name] != content {
+			t.Errorf("%s = %q, want %q", name, got[name], content)
+		}
+	}
+}
+
+func TestExportRootNames(t *testing.T) {
+	got := exportRootNames([]string{"/a/docs", "/b/docs-2", "/c/docs", "/d/notes", "/e/docs"})
+	want := []string{"docs", "docs-2", "docs-3", "notes", "docs-4"}
+	if !slices.Equal(got, want) {
+		t.Errorf("exportRootNames() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"backend/domain"
+	"backend/handler"
+	"backend/markdown"
+	"context"
+	"fmt"
+)
+
+type lintResult struct {
+	Path        string                `json:"path"`
+	Diagnostics []markdown.Diagnostic `json:"diagnostics"`
+}
+
+// runLint はワークスペースのドキュメントを現在のワークスペースの規則で検査する
+// CIで使えるよう、errorの問題（--strictの場合はwarningも）があれば失敗する
+func runLint(app *App, ctx context.Context, args []string) error {
+	fs := app.newFlagSet("lint")
+	kind := fs.String("kind", domain.LocalRepoKind.String(), "kind of document source")
+	dir := fs.String("dir", "", "directory to lint (default: directories of the active workspace)")
+	asJSON := fs.Bool("json", false, "output as JSON")
+	fix := fs.Bool("fix", false, "apply automatic fixes and write the documents back")
+	strict := fs.Bool("strict", false, "fail on warnings as well as errors")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return ErrUsage
+	}
+
+	appConfig, err := app.ConfigProvider.Load(ctx)
+	if err != nil {
+		return err
+	}
+	roots, err := searchRoots(appConfig, *dir)
+	if err != nil {
+		return err
+	}
+	documentsProvider, err := findProvider[handler.DocumentsProvider](app, *kind, handler.CapabilityList)
+	if err != nil {
+		return err
+	}
+	contentProvider, err := findProvider[handler.DocumentContentProvider](app, *kind, handler.CapabilityRead)
+	if err != nil {
+		return err
+	}
+	var updateProvider handler.DocumentContentUpdateProvider
+	if *fix {
+		updateProvider, err = findProvider[handler.DocumentContentUpdateProvider](app, *kind, handler.CapabilityWrite)
+		if err != nil {
+			return err
+		}
+	}
+
+	opts := handler.LintOptionsFor(appConfig)
+	results := []lintResult{}
+	var errorCount, warningCount int
+	for _, root := range roots {
+		docs, err := documentsProvider.GetDocuments(ctx, root, handler.DocumentConditionFor(appConfig))
+		if err != nil {
+			return err
+		}
+
+		for _, doc := range docs {
+			content, err := contentProvider.GetDocumentContent(ctx, doc.Path)
+			if err != nil {
+				fmt.Fprintf(app.Stderr, "skip %s: %v\n", doc.Path, err)
+				continue
+			}
+
+			diagnostics := markdown.Lint(content, opts)
+			if *fix {
+				// 修正後に残った問題だけを報告する
+				if fixed := markdown.ApplyFixes(content, diagnostics); fixed != content {
+					if err := updateProvider.UpdateDocumentContent(ctx, doc.Path, fixed); err != nil {
+						return fmt.Errorf("%s: %w", doc.Path, err)
+					}
+					diagnostics = markdown.Lint(fixed, opts)
+				}
+			}
+			if len(diagnostics) == 0 {
+				continue
+			}
+
+			for _, d := range diagnostics {
+				switch d.Severity {
+				case markdown.SeverityError:
+					errorCount++
+				case markdown.SeverityWarning:
+					warningCount++
+				}
+			}
+			results = append(results, lintResult{Path: doc.Path, Diagnostics: diagnostics})
+		}
+	}
+
+	if *asJSON {
+		if err := app.printJSON(results); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range results {
+			for _, d := range r.Diagnostics {
+				fmt.Fprintf(app.Stdout, "%s:%d:%d: %s %s [%s]\n", r.Path, d.Line, d.Column, d.Severity, d.Message, d.Rule)
+			}
+		}
+	}
+
+	if errorCount > 0 || *strict && warningCount > 0 {
+		return fmt.Errorf("lint found %d errors and %d warnings", errorCount, warningCount)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestLintFailsOnErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		args    []string
+		wantErr bool
+	}{
+		{"clean document", "# Title\n\ntext\n", nil, false},
+		{"skipped heading level", "# Title\n\n### Section\n", nil, true},
+		{"image without alt text", "# Title\n\n![](image.png)\n", nil, true},
+		{"warning only", "# Title\n\n## A\n\n## A\n", nil, false},
+		{"warning with strict", "# Title\n\n## A\n\n## A\n", []string{"--strict"}, true},
+		{"info with strict", "# Title\n\nhttps://example.com\n", []string{"--strict"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFile(t, filepath.Join(dir, "doc.md"), tt.content)
+			app, _ := newTestApp(t, dir)
+			err := app.Run(context.Background(), append([]string{"lint"}, tt.args...))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("lint = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"backend/domain"
+	"backend/handler"
+	"context"
+	"fmt"
+)
+
+func runLs(app *App, ctx context.Context, args []string) error {
+	fs := app.newFlagSet("ls")
+	kind := fs.String("kind", domain.LocalRepoKind.String(), "kind of document source")
+	asJSON := fs.Bool("json", false, "output as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return ErrUsage
+	}
+
+	provider, err := findProvider[handler.DirectoryProvider](app, *kind, handler.CapabilityBrowse)
+	if err != nil {
+		return err
+	}
+	items, err := provider.GetDirectory(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return app.printJSON(items)
+	}
+	for _, item := range items {
+		if item.IsDir {
+			fmt.Fprintln(app.Stdout, item.Name+"/")
+			continue
+		}
+		fmt.Fprintln(app.Stdout, item.Name)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"backend/domain"
+	"backend/handler"
+	"context"
+	"fmt"
+	"strings"
+)
+
+type searchMatch struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// runSearch はワークスペースのドキュメントから大文字小文字を区別せずに検索する
+// ファイル名に一致した場合は行番号0として出力する
+func runSearch(app *App, ctx context.Context, args []string) error {
+	fs := app.newFlagSet("search")
+	kind := fs.String("kind", domain.LocalRepoKind.String(), "kind of document source")
+	dir := fs.String("dir", "", "directory to search (default: directories of the active workspace)")
+	asJSON := fs.Bool("json", false, "output as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return ErrUsage
+	}
+	query := strings.ToLower(strings.Join(fs.Args(), " "))
+
+	appConfig, err := app.ConfigProvider.Load(ctx)
+	if err != nil {
+		return err
+	}
+	roots, err := searchRoots(appConfig, *dir)
+	if err != nil {
+		return err
+	}
+	documentsProvider, err := findProvider[handler.DocumentsProvider](app, *kind, handler.CapabilityList)
+	if err != nil {
+		return err
+	}
+	contentProvider, err := findProvider[handler.DocumentContentProvider](app, *kind, handler.CapabilityRead)
+	if err != nil {
+		return err
+	}
+
+	matches := []searchMatch{}
+	for _, root := range roots {
+		docs, err := documentsProvider.GetDocuments(ctx, root, handler.DocumentConditionFor(appConfig))
+		if err != nil {
+			return err
+		}
+
+		for _, doc := range docs {
+			if strings.Contains(strings.ToLower(doc.Name), query) {
+				matches = append(matches, searchMatch{Path: doc.Path, Line: 0, Text: doc.Name})
+			}
+
+			content, err := contentProvider.GetDocumentContent(ctx, doc.Path)
+			if err != nil {
+				fmt.Fprintf(app.Stderr, "skip %s: %v\n", doc.Path, err)
+				continue
+			}
+			for i, line := range strings.Split(content, "\n") {
+				if strings.Contains(strings.ToLower(line), query) {
+					matches = append(matches, searchMatch{Path: doc.Path, Line: i + 1, Text: line})
+				}
+			}
+		}
+	}
+
+	if *asJSON {
+		return app.printJSON(matches)
+	}
+	for _, m := range matches {
+		fmt.Fprintf(app.Stdout, "%s:%d: %s\n", m.Path, m.Line, m.Text)
+	}
+	return nil
+}
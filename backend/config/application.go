@@ -1,10 +1,16 @@
 package config
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
 
 type AppConfigProvider interface {
-	Load() (*AppConfig, error)
-	Save(*AppConfig) error // AppModeは更新しない
+	Load(ctx context.Context) (*AppConfig, error)
+	Save(ctx context.Context, appConfig *AppConfig) error // AppModeは更新しない
 }
 
 type AppMode struct {
@@ -23,6 +29,8 @@ func ParseAppMode(value string) (AppMode, error) {
 		return CLI, nil
 	case "native":
 		return Native, nil
+	case "demo":
+		return Demo, nil
 	default:
 		return AppMode{}, fmt.Errorf("unknown app mode: %s", value)
 	}
@@ -32,19 +40,83 @@ var (
 	Web    = AppMode{value: "web"}
 	CLI    = AppMode{value: "cli"}
 	Native = AppMode{value: "native"}
+	// Demo はサンプルのドキュメントをメモリ上に展開し、ディスクに一切触れずに起動する
+	Demo = AppMode{value: "demo"}
 )
 
 type AppConfig struct {
-	Github    Github
-	LocalFile LocalFile
-	AppMode   AppMode
+	// GithubとLocalFileは現在のワークスペースの内容
+	Github              Github
+	LocalFile           LocalFile
+	Workspaces          []Workspace `json:",omitempty"`
+	ActiveWorkspaceName string      `json:",omitempty"`
+	S3                  S3          `json:",omitzero"`
+	SFTP                SFTP        `json:",omitzero"`
+	// Plugins は設定ファイルでのみ変更できる（APIからは変更できない）
+	Plugins []Plugin `json:",omitempty" readOnly:"true"`
+	AppMode AppMode
 }
 
 type Github struct {
-	AccessToken string
+	AccessToken Secret
 	IgnoreRepos []string
 }
 
 type LocalFile struct {
 	Directories []string
+	// AllowedRoots はwebモードでDirectoriesとして指定できるディレクトリ（読み取り専用）
+	// 空の場合は制限なし
+	AllowedRoots []string `json:",omitempty" readOnly:"true"`
+}
+
+// ValidationError は設定値が不正な場合に返されるエラー
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid config %s: %s", e.Field, e.Message)
+}
+
+// Validate は設定値を検証し、不正な値それぞれについてValidationErrorを返す
+func (c *AppConfig) Validate() error {
+	var errs []error
+
+	errs = append(errs, validateDirectories("LocalFile.Directories", c.LocalFile.Directories)...)
+	errs = append(errs, validateWorkspaces(c)...)
+	errs = append(errs, validatePlugins(c.Plugins)...)
+	errs = append(errs, validateS3(c.S3)...)
+	errs = append(errs, validateSFTP(c.SFTP)...)
+
+	for i, repo := range c.Github.IgnoreRepos {
+		if strings.TrimSpace(repo) == "" {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("Github.IgnoreRepos[%d]", i),
+				Message: "must not be empty",
+			})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateDirectories(field string, directories []string) []error {
+	var errs []error
+
+	seen := map[string]bool{}
+	for i, dir := range directories {
+		field := fmt.Sprintf("%s[%d]", field, i)
+		switch {
+		case dir == "":
+			errs = append(errs, &ValidationError{Field: field, Message: "must not be empty"})
+		case !filepath.IsAbs(dir):
+			errs = append(errs, &ValidationError{Field: field, Message: fmt.Sprintf("%s is not an absolute path", dir)})
+		case seen[filepath.Clean(dir)]:
+			errs = append(errs, &ValidationError{Field: field, Message: fmt.Sprintf("%s is listed more than once", dir)})
+		}
+		seen[filepath.Clean(dir)] = true
+	}
+
+	return errs
 }
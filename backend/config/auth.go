@@ -0,0 +1,125 @@
+package config
+
+import (
+	"backend/util"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+)
+
+type AuthMode struct {
+	value string
+}
+
+func (m AuthMode) String() string {
+	return m.value
+}
+
+var (
+	// AuthAuto はループバック以外で待ち受ける場合のみ認証を要求する
+	AuthAuto = AuthMode{value: "auto"}
+	// AuthRequired は常に認証を要求する
+	AuthRequired = AuthMode{value: "required"}
+	// AuthDisabled は認証を行わない
+	AuthDisabled = AuthMode{value: "disabled"}
+)
+
+func ParseAuthMode(value string) (AuthMode, error) {
+	switch value {
+	case "auto":
+		return AuthAuto, nil
+	case "required":
+		return AuthRequired, nil
+	case "disabled":
+		return AuthDisabled, nil
+	default:
+		return AuthMode{}, fmt.Errorf("unknown auth mode: %s", value)
+	}
+}
+
+// 起動時トークンで認証したユーザー名
+const LaunchTokenUser = "owner"
+
+// ユーザー名を省略した固定トークンのユーザー名
+const DefaultAPITokenUser = "api"
+
+// APIToken はユーザーに紐づく固定トークン
+// AUTH_API_TOKENSに "user:token" の形式でカンマ区切りで指定する
+type APIToken struct {
+	User  string
+	Token string
+}
+
+type AuthConfig struct {
+	Mode AuthMode
+	// LaunchToken は起動ごとに生成されるトークン（AUTH_TOKENで外部から渡すこともできる）
+	LaunchToken string
+	// APITokens はスクリプトやwebモードのユーザーが利用する固定トークン
+	APITokens []APIToken
+	// AllowedOrigins は更新系リクエストを許可する追加のOrigin
+	AllowedOrigins []string
+}
+
+func NewAuthConfig() (*AuthConfig, error) {
+	mode, err := ParseAuthMode(util.LookupEnvOr("AUTH_MODE", AuthAuto.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	launchToken := util.LookupEnvOr("AUTH_TOKEN", "")
+	if launchToken == "" {
+		launchToken, err = generateToken()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &AuthConfig{
+		Mode:           mode,
+		LaunchToken:    launchToken,
+		APITokens:      parseAPITokens(util.LookupEnvOr("AUTH_API_TOKENS", "")),
+		AllowedOrigins: util.SplitList(util.LookupEnvOr("AUTH_ALLOWED_ORIGINS", "")),
+	}, nil
+}
+
+// Enabled はhostで待ち受ける場合に認証が必要かを返す
+func (c *AuthConfig) Enabled(host string) bool {
+	switch c.Mode {
+	case AuthRequired:
+		return true
+	case AuthDisabled:
+		return false
+	default:
+		return !IsLoopbackHost(host)
+	}
+}
+
+func IsLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func parseAPITokens(value string) []APIToken {
+	var tokens []APIToken
+	for _, item := range util.SplitList(value) {
+		user, token, ok := strings.Cut(item, ":")
+		if !ok {
+			user, token = DefaultAPITokenUser, item
+		}
+		tokens = append(tokens, APIToken{User: user, Token: token})
+	}
+	return tokens
+}
@@ -0,0 +1,34 @@
+// Package configtest はテストで使う設定のプロバイダーを提供する
+package configtest
+
+import (
+	"backend/config"
+	"context"
+	"sync"
+)
+
+// Provider は保持している設定を返すAppConfigProvider
+// Saveで保存した設定は以降のLoadで返す
+type Provider struct {
+	mu        sync.Mutex
+	appConfig *config.AppConfig
+}
+
+var _ config.AppConfigProvider = (*Provider)(nil)
+
+func NewProvider(appConfig *config.AppConfig) *Provider {
+	return &Provider{appConfig: appConfig}
+}
+
+func (p *Provider) Load(ctx context.Context) (*config.AppConfig, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.appConfig, nil
+}
+
+func (p *Provider) Save(ctx context.Context, appConfig *config.AppConfig) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.appConfig = appConfig
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"backend/markdown"
+	"fmt"
+)
+
+// LintRules はワークスペースで使うMarkdownのリンターの設定
+// 空の場合は全ての規則を既定の重大度で使用する
+type LintRules struct {
+	Disabled   []string          `json:"disabled,omitempty" example:"[\"line-length\"]" doc:"IDs of lint rules to turn off"`
+	Severity   map[string]string `json:"severity,omitempty" example:"{\"no-bare-urls\":\"warning\"}" doc:"Severity (error, warning or info) per lint rule ID"`
+	LineLength int               `json:"line_length,omitempty" example:"120" doc:"Maximum line length for the line-length rule"`
+}
+
+func (r LintRules) IsZero() bool {
+	return len(r.Disabled) == 0 && len(r.Severity) == 0 && r.LineLength == 0
+}
+
+// Options はリンターに渡す設定を返す
+// 検証済みの設定を前提とし、不正な重大度は無視する
+func (r LintRules) Options() markdown.LintOptions {
+	opts := markdown.LintOptions{
+		Disabled:   r.Disabled,
+		Severity:   map[string]markdown.Severity{},
+		LineLength: r.LineLength,
+	}
+	for id, value := range r.Severity {
+		if severity, err := markdown.ParseSeverity(value); err == nil {
+			opts.Severity[id] = severity
+		}
+	}
+	return opts
+}
+
+func validateLintRules(field string, r LintRules) []error {
+	var errs []error
+	for _, id := range r.Disabled {
+		if !markdown.IsLintRule(id) {
+			errs = append(errs, &ValidationError{Field: field + ".Disabled", Message: fmt.Sprintf("unknown lint rule: %s", id)})
+		}
+	}
+	for id, value := range r.Severity {
+		if !markdown.IsLintRule(id) {
+			errs = append(errs, &ValidationError{Field: field + ".Severity", Message: fmt.Sprintf("unknown lint rule: %s", id)})
+		}
+		if _, err := markdown.ParseSeverity(value); err != nil {
+			errs = append(errs, &ValidationError{Field: field + ".Severity", Message: err.Error()})
+		}
+	}
+	if r.LineLength < 0 {
+		errs = append(errs, &ValidationError{Field: field + ".LineLength", Message: "must not be negative"})
+	}
+	return errs
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"backend/util"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+type LogConfig struct {
+	Level  slog.Level
+	Format string // "text" or "json"
+}
+
+func NewLogConfig() (*LogConfig, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(util.LookupEnvOr("LOG_LEVEL", "info"))); err != nil {
+		return nil, fmt.Errorf("invalid LOG_LEVEL: %w", err)
+	}
+
+	format := strings.ToLower(util.LookupEnvOr("LOG_FORMAT", "text"))
+	if format != "text" && format != "json" {
+		return nil, fmt.Errorf("invalid LOG_FORMAT: %s (expected text or json)", format)
+	}
+
+	return &LogConfig{
+		Level:  level,
+		Format: format,
+	}, nil
+}
+
+// NewLogger は設定に従ってwに出力するロガーを返す
+func (c *LogConfig) NewLogger(w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: c.Level}
+	if c.Format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
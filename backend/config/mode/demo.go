@@ -0,0 +1,61 @@
+package mode
+
+import (
+	"backend/config"
+	"context"
+	"slices"
+	"sync"
+)
+
+// demo は設定をメモリ上にのみ保持するプロバイダー
+// 変更はサーバーを再起動すると失われる
+type demo struct {
+	mu  sync.Mutex
+	cfg localAppConfig
+}
+
+var _ config.AppConfigProvider = (*demo)(nil)
+
+// DemoWorkspaceName はデモで作成されるワークスペース名
+const DemoWorkspaceName = "demo"
+
+// NewDemoProvider はrootのサンプルのドキュメントを表示するワークスペースのみを持つプロバイダーを返す
+func NewDemoProvider(root string) *demo {
+	var cfg localAppConfig
+	cfg.Version = currentConfigVersion
+	cfg.ActiveWorkspace = DemoWorkspaceName
+	cfg.Workspaces = []config.Workspace{
+		{Name: DemoWorkspaceName, Directories: []string{root}},
+	}
+	return &demo{cfg: cfg}
+}
+
+func (p *demo) Load(ctx context.Context) (*config.AppConfig, error) {
+	p.mu.Lock()
+	cfg := p.cfg
+	p.mu.Unlock()
+
+	// 呼び出し側が変更しても保持している設定に影響しないようにコピーする
+	cfg.Workspaces = slices.Clone(cfg.Workspaces)
+	appConfig := cfg.toAppConfig()
+	appConfig.AppMode = config.Demo
+	return appConfig, nil
+}
+
+// AppModeは更新しない
+func (p *demo) Save(ctx context.Context, appConfig *config.AppConfig) error {
+	if err := appConfig.Validate(); err != nil {
+		return err
+	}
+
+	// デモではディスク上の鍵や外部のホストに接続させない
+	cfg := newLocalAppConfig(appConfig)
+	cfg.Plugins = nil
+	cfg.S3 = nil
+	cfg.SFTP = nil
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cfg = cfg
+	return nil
+}
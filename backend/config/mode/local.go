@@ -3,20 +3,27 @@ package mode
 import (
 	"backend/config"
 	"backend/util"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
 )
 
 type local struct {
 	configPath string
+	cipher     *secretCipher
 }
 
-func NewLocalProvider() (*local, error) {
+// NewLocalProvider はユーザーの設定ディレクトリに設定を保存するプロバイダーを返す
+// passphraseを指定した場合は設定ファイル内の秘密情報を暗号化して保存する
+func NewLocalProvider(passphrase string) (*local, error) {
 	configDir, err := util.UserConfigDir()
-	fmt.Println("User config directory:", configDir)
+	log.Println("User config directory:", configDir)
 	if err != nil {
 		return nil, err
 	}
@@ -30,79 +37,183 @@ func NewLocalProvider() (*local, error) {
 	}
 	return &local{
 		configPath: configPath,
+		cipher:     newSecretCipher(passphrase),
 	}, nil
 }
 
-var _ config.AppConfigProvider = (*local)(nil)
+var _ config.WatchableAppConfigProvider = (*local)(nil)
+
+func (p *local) ModTime() (time.Time, error) {
+	info, err := os.Stat(p.configPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
 
 type localAppConfig struct {
-	Github struct {
-		AccessToken string   `json:"access_token"`
-		IgnoreRepos []string `json:"ignore_repos"`
+	Version int `json:"version"`
+	Github  struct {
+		AccessToken string `json:"access_token"`
 	} `json:"github"`
-	LocalFile struct {
-		Directories []string `json:"directories"`
-	} `json:"local_file"`
+	ActiveWorkspace string             `json:"active_workspace"`
+	Workspaces      []config.Workspace `json:"workspaces"`
+	S3              *localS3           `json:"s3,omitempty"`
+	SFTP            *localSFTP         `json:"sftp,omitempty"`
+	Plugins         []config.Plugin    `json:"plugins,omitempty"`
+}
+
+type localS3 struct {
+	Endpoint        string `json:"endpoint"`
+	Region          string `json:"region,omitempty"`
+	Bucket          string `json:"bucket"`
+	Prefix          string `json:"prefix,omitempty"`
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+	Insecure        bool   `json:"insecure,omitempty"`
+	PathStyle       bool   `json:"path_style,omitempty"`
+}
+
+type localSFTP struct {
+	Host           string `json:"host"`
+	User           string `json:"user"`
+	KeyPath        string `json:"key_path"`
+	KeyPassphrase  string `json:"key_passphrase,omitempty"`
+	KnownHostsPath string `json:"known_hosts_path,omitempty"`
+	Root           string `json:"root"`
+}
+
+func newLocalAppConfig(appConfig *config.AppConfig) localAppConfig {
+	// 呼び出し元の設定を変更しないようにコピーしてから現在のワークスペースに書き戻す
+	synced := *appConfig
+	synced.Workspaces = slices.Clone(appConfig.Workspaces)
+	synced.SyncActiveWorkspace()
+
+	var cfg localAppConfig
+	cfg.Version = currentConfigVersion
+	cfg.Github.AccessToken = synced.Github.AccessToken.Reveal()
+	cfg.ActiveWorkspace = synced.ActiveWorkspaceName
+	cfg.Workspaces = synced.Workspaces
+	cfg.Plugins = synced.Plugins
+	if s := synced.S3; s.Configured() {
+		cfg.S3 = &localS3{
+			Endpoint:        s.Endpoint,
+			Region:          s.Region,
+			Bucket:          s.Bucket,
+			Prefix:          s.Prefix,
+			AccessKeyID:     s.AccessKeyID,
+			SecretAccessKey: s.SecretAccessKey.Reveal(),
+			Insecure:        s.Insecure,
+			PathStyle:       s.PathStyle,
+		}
+	}
+	if s := synced.SFTP; s.Configured() {
+		cfg.SFTP = &localSFTP{
+			Host:           s.Host,
+			User:           s.User,
+			KeyPath:        s.KeyPath,
+			KeyPassphrase:  s.KeyPassphrase.Reveal(),
+			KnownHostsPath: s.KnownHostsPath,
+			Root:           s.Root,
+		}
+	}
+	return cfg
+}
+
+// toAppConfig はAppModeを設定しないので呼び出し側で設定する
+func (cfg localAppConfig) toAppConfig() *config.AppConfig {
+	appConfig := &config.AppConfig{
+		Github: config.Github{
+			AccessToken: config.Secret(cfg.Github.AccessToken),
+		},
+		Workspaces:          cfg.Workspaces,
+		ActiveWorkspaceName: cfg.ActiveWorkspace,
+		Plugins:             cfg.Plugins,
+	}
+	if s := cfg.S3; s != nil {
+		appConfig.S3 = config.S3{
+			Endpoint:        s.Endpoint,
+			Region:          s.Region,
+			Bucket:          s.Bucket,
+			Prefix:          s.Prefix,
+			AccessKeyID:     s.AccessKeyID,
+			SecretAccessKey: config.Secret(s.SecretAccessKey),
+			Insecure:        s.Insecure,
+			PathStyle:       s.PathStyle,
+		}
+	}
+	if s := cfg.SFTP; s != nil {
+		appConfig.SFTP = config.SFTP{
+			Host:           s.Host,
+			User:           s.User,
+			KeyPath:        s.KeyPath,
+			KeyPassphrase:  config.Secret(s.KeyPassphrase),
+			KnownHostsPath: s.KnownHostsPath,
+			Root:           s.Root,
+		}
+	}
+	appConfig.ApplyActiveWorkspace()
+	return appConfig
 }
 
-func (p *local) Load() (*config.AppConfig, error) {
-	fmt.Println("Loading configuration from:", p.configPath)
-	file, err := os.Open(p.configPath)
+func (p *local) Load(ctx context.Context) (*config.AppConfig, error) {
+	log.Println("Loading configuration from:", p.configPath)
+	b, err := os.ReadFile(p.configPath)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
-	var cfg localAppConfig
-	if err := json.NewDecoder(file).Decode(&cfg); err != nil {
-		return nil, err
+	cfg, fromVersion, err := decodeLocalAppConfig(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", p.configPath, err)
+	}
+	if err := p.cipher.openSecrets(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", p.configPath, err)
 	}
 
-	fmt.Println("Loaded configuration:", cfg)
+	// 古いバージョンの設定ファイルは退避してから新しい形式で書き直す
+	if fromVersion < currentConfigVersion {
+		backupPath, err := backupConfig(p.configPath, fromVersion)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Migrated configuration from version %d to %d (backup: %s)", fromVersion, currentConfigVersion, backupPath)
+		if err := p.write(cfg); err != nil {
+			return nil, err
+		}
+	}
 
-	return &config.AppConfig{
-		Github: config.Github{
-			AccessToken: cfg.Github.AccessToken,
-			IgnoreRepos: cfg.Github.IgnoreRepos,
-		},
-		LocalFile: config.LocalFile{
-			Directories: cfg.LocalFile.Directories,
-		},
-		AppMode: config.CLI,
-	}, nil
+	appConfig := cfg.toAppConfig()
+	appConfig.AppMode = config.CLI
+
+	log.Printf("Loaded configuration from %s", p.configPath)
+	return appConfig, nil
 }
 
 // AppModeは更新しない
-func (p *local) Save(appConfig *config.AppConfig) error {
-	file, err := os.OpenFile(p.configPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
+func (p *local) Save(ctx context.Context, appConfig *config.AppConfig) error {
+	if err := appConfig.Validate(); err != nil {
 		return err
 	}
-	defer file.Close()
+	return p.write(newLocalAppConfig(appConfig))
+}
 
-	cfg := localAppConfig{
-		Github: struct {
-			AccessToken string   `json:"access_token"`
-			IgnoreRepos []string `json:"ignore_repos"`
-		}{
-			AccessToken: appConfig.Github.AccessToken,
-			IgnoreRepos: appConfig.Github.IgnoreRepos,
-		},
-		LocalFile: struct {
-			Directories []string `json:"directories"`
-		}{
-			Directories: appConfig.LocalFile.Directories,
-		},
+func (p *local) write(cfg localAppConfig) error {
+	if err := p.cipher.sealSecrets(&cfg); err != nil {
+		return err
 	}
 
 	b, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return err
 	}
-	if _, err := file.Write(b); err != nil {
+
+	// 書き込み途中のファイルを変更の監視で読み込まないように、別のファイルに書いてから置き換える
+	tmp := p.configPath + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
 		return err
 	}
-	return nil
+	return os.Rename(tmp, p.configPath)
 }
 
 func initConfig(configPath string) error {
@@ -116,7 +227,7 @@ func initConfig(configPath string) error {
 	defer file.Close()
 
 	// 初期設定を書き込む
-	defaultConfig := localAppConfig{}
+	defaultConfig := newLocalAppConfig(&config.AppConfig{})
 	b, err := json.MarshalIndent(defaultConfig, "", "  ")
 	if err != nil {
 		return err
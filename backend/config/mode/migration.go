@@ -0,0 +1,147 @@
+package mode
+
+import (
+	"backend/config"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 現在の設定ファイルのスキーマバージョン
+const currentConfigVersion = 2
+
+// configMigration は1つ前のバージョンの設定を次のバージョンに変換する
+type configMigration func(raw map[string]any) error
+
+// configMigrations[i] はバージョンiからi+1への変換
+var configMigrations = []configMigration{
+	// v0: versionフィールドが無い初期の形式（フィールド構成はv1と同じ）
+	func(raw map[string]any) error {
+		return nil
+	},
+	// v1: 単一のディレクトリ一覧から名前付きワークスペースに移行する
+	func(raw map[string]any) error {
+		workspace := map[string]any{"name": config.DefaultWorkspaceName}
+
+		if localFile, ok := raw["local_file"].(map[string]any); ok {
+			workspace["directories"] = localFile["directories"]
+		}
+		delete(raw, "local_file")
+
+		if github, ok := raw["github"].(map[string]any); ok {
+			workspace["ignore_repos"] = github["ignore_repos"]
+			delete(github, "ignore_repos")
+		}
+
+		raw["workspaces"] = []any{workspace}
+		raw["active_workspace"] = config.DefaultWorkspaceName
+		return nil
+	},
+}
+
+// decodeLocalAppConfig は設定ファイルを必要に応じてマイグレーションしてから厳密に読み込む
+// 戻り値のfromVersionは読み込んだファイルのバージョン
+func decodeLocalAppConfig(b []byte) (cfg localAppConfig, fromVersion int, err error) {
+	var raw map[string]any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return cfg, 0, describeJSONError(b, err)
+	}
+	if raw == nil {
+		return cfg, 0, errors.New("config must be a JSON object")
+	}
+
+	fromVersion, err = configVersion(raw)
+	if err != nil {
+		return cfg, 0, err
+	}
+	if fromVersion > currentConfigVersion {
+		return cfg, fromVersion, fmt.Errorf("config version %d is newer than supported version %d", fromVersion, currentConfigVersion)
+	}
+
+	for v := fromVersion; v < currentConfigVersion; v++ {
+		if err := configMigrations[v](raw); err != nil {
+			return cfg, fromVersion, fmt.Errorf("failed to migrate config from version %d to %d: %w", v, v+1, err)
+		}
+		raw["version"] = v + 1
+	}
+
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return cfg, fromVersion, err
+	}
+
+	// 未知のフィールドは保存時に失われるため読み込み時にエラーにする
+	decoder := json.NewDecoder(bytes.NewReader(migrated))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return cfg, fromVersion, describeJSONError(migrated, err)
+	}
+
+	if err := cfg.toAppConfig().Validate(); err != nil {
+		return cfg, fromVersion, err
+	}
+
+	return cfg, fromVersion, nil
+}
+
+func configVersion(raw map[string]any) (int, error) {
+	value, ok := raw["version"]
+	if !ok {
+		return 0, nil
+	}
+	version, ok := value.(float64)
+	if !ok || version < 0 || version != float64(int(version)) {
+		return 0, fmt.Errorf("config version must be a non-negative integer: %v", value)
+	}
+	return int(version), nil
+}
+
+// backupConfig はマイグレーション前の設定ファイルを同じディレクトリに退避する
+func backupConfig(configPath string, version int) (string, error) {
+	b, err := os.ReadFile(configPath)
+	if err != nil {
+		return "", err
+	}
+	backupPath := filepath.Join(
+		filepath.Dir(configPath),
+		fmt.Sprintf("config.v%d.%s.bak.json", version, time.Now().Format("20060102150405")),
+	)
+	if err := os.WriteFile(backupPath, b, 0600); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
+
+// describeJSONError はJSONのエラーに行と列の情報を付与する
+func describeJSONError(b []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, col := lineAndColumn(b, syntaxErr.Offset)
+		return fmt.Errorf("invalid JSON at line %d, column %d: %w", line, col, err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Errorf("field %q must be %s but got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+	}
+	return err
+}
+
+func lineAndColumn(b []byte, offset int64) (int, int) {
+	if offset > int64(len(b)) {
+		offset = int64(len(b))
+	}
+	line, col := 1, 1
+	for _, c := range b[:offset] {
+		if c == '\n' {
+			line++
+			col = 1
+			continue
+		}
+		col++
+	}
+	return line, col
+}
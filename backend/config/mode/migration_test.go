@@ -0,0 +1,104 @@
+package mode
+
+import (
+	"backend/config"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDecodeLocalAppConfigMigrates(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		wantVersion int
+	}{
+		{
+			name:        "v0 without version",
+			content:     `{"github":{"access_token":"token","ignore_repos":["o/ignored"]},"local_file":{"directories":["/docs"]}}`,
+			wantVersion: 0,
+		},
+		{
+			name:        "v1",
+			content:     `{"version":1,"github":{"access_token":"token","ignore_repos":["o/ignored"]},"local_file":{"directories":["/docs"]}}`,
+			wantVersion: 1,
+		},
+		{
+			name:        "current version",
+			content:     `{"version":2,"github":{"access_token":"token"},"active_workspace":"default","workspaces":[{"name":"default","directories":["/docs"],"ignore_repos":["o/ignored"]}]}`,
+			wantVersion: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, fromVersion, err := decodeLocalAppConfig([]byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fromVersion != tt.wantVersion {
+				t.Errorf("fromVersion = %d, want %d", fromVersion, tt.wantVersion)
+			}
+			if cfg.Version != currentConfigVersion {
+				t.Errorf("version = %d, want %d", cfg.Version, currentConfigVersion)
+			}
+			if cfg.Github.AccessToken != "token" {
+				t.Errorf("access token = %q, want token", cfg.Github.AccessToken)
+			}
+			if cfg.ActiveWorkspace != config.DefaultWorkspaceName || len(cfg.Workspaces) != 1 {
+				t.Fatalf("workspaces = %+v, active = %q", cfg.Workspaces, cfg.ActiveWorkspace)
+			}
+			workspace := cfg.Workspaces[0]
+			if workspace.Name != config.DefaultWorkspaceName ||
+				!slices.Equal(workspace.Directories, []string{"/docs"}) ||
+				!slices.Equal(workspace.IgnoreRepos, []string{"o/ignored"}) {
+				t.Errorf("workspace = %+v", workspace)
+			}
+		})
+	}
+}
+
+func TestDecodeLocalAppConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"newer version", `{"version":3}`, "newer than supported version 2"},
+		{"version is not a number", `{"version":"2"}`, "non-negative integer"},
+		{"version is not an integer", `{"version":1.5}`, "non-negative integer"},
+		{"negative version", `{"version":-1}`, "non-negative integer"},
+		{"unknown field", `{"version":2,"unknown":true}`, `unknown field "unknown"`},
+		{"syntax error", "{\n  \"version\": 2,\n}", "line 3,"},
+		{"wrong type", `{"version":2,"workspaces":"default"}`, `field "workspaces" must be`},
+		{"null", `null`, "must be a JSON object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := decodeLocalAppConfig([]byte(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBackupConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	content := `{"local_file":{"directories":["/docs"]}}`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	backupPath, err := backupConfig(configPath, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(backupPath) != filepath.Dir(configPath) || !strings.HasPrefix(filepath.Base(backupPath), "config.v0.") {
+		t.Errorf("backup path = %s", backupPath)
+	}
+	if b, err := os.ReadFile(backupPath); err != nil || string(b) != content {
+		t.Errorf("backup = %q, %v, want the original content", b, err)
+	}
+}
@@ -0,0 +1,193 @@
+package mode
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// 暗号化された値の接頭辞
+const encryptedSecretPrefix = "enc:v1:"
+
+const (
+	secretSaltSize   = 16
+	secretKeySize    = 32
+	secretIterations = 600_000
+)
+
+var ErrPassphraseRequired = errors.New("config contains encrypted secrets but no passphrase is configured (set REPO_WISE_CONFIG_PASSPHRASE)")
+
+// secretCipher はパスフレーズから導出した鍵で設定ファイル内の秘密情報を暗号化する
+// nilの場合は暗号化せずに平文で保存する
+type secretCipher struct {
+	passphrase string
+
+	mu   sync.Mutex
+	keys map[string][]byte // 鍵導出は重いのでソルトごとにキャッシュする
+}
+
+func newSecretCipher(passphrase string) *secretCipher {
+	if passphrase == "" {
+		return nil
+	}
+	return &secretCipher{
+		passphrase: passphrase,
+		keys:       map[string][]byte{},
+	}
+}
+
+func (c *secretCipher) key(salt []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key, ok := c.keys[string(salt)]; ok {
+		return key, nil
+	}
+	key, err := pbkdf2.Key(sha256.New, c.passphrase, salt, secretIterations, secretKeySize)
+	if err != nil {
+		return nil, err
+	}
+	c.keys[string(salt)] = key
+	return key, nil
+}
+
+// seal は平文を暗号化する
+// 同じパスフレーズで保存するたびに鍵導出しないよう、キャッシュ済みのソルトを再利用する
+func (c *secretCipher) seal(plaintext string) (string, error) {
+	if c == nil || plaintext == "" {
+		return plaintext, nil
+	}
+
+	salt := c.anySalt()
+	if salt == nil {
+		salt = make([]byte, secretSaltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+	}
+	gcm, err := c.gcm(salt)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := append(append([]byte{}, salt...), nonce...)
+	sealed = gcm.Seal(sealed, nonce, []byte(plaintext), nil)
+	return encryptedSecretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open は暗号化された値を復号する（平文の値はそのまま返す）
+func (c *secretCipher) open(value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, encryptedSecretPrefix)
+	if !ok {
+		return value, nil
+	}
+	if c == nil {
+		return "", ErrPassphraseRequired
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.New("encrypted secret is corrupted")
+	}
+	if len(sealed) < secretSaltSize {
+		return "", errors.New("encrypted secret is corrupted")
+	}
+	salt, rest := sealed[:secretSaltSize], sealed[secretSaltSize:]
+
+	gcm, err := c.gcm(salt)
+	if err != nil {
+		return "", err
+	}
+	if len(rest) < gcm.NonceSize() {
+		return "", errors.New("encrypted secret is corrupted")
+	}
+	nonce, ciphertext := rest[:gcm.NonceSize()], rest[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt secret: wrong passphrase?")
+	}
+	return string(plaintext), nil
+}
+
+func (c *secretCipher) gcm(salt []byte) (cipher.AEAD, error) {
+	key, err := c.key(salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (c *secretCipher) anySalt() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for salt := range c.keys {
+		return []byte(salt)
+	}
+	return nil
+}
+
+// openSecrets はlocalAppConfig内の秘密情報を復号する
+func (c *secretCipher) openSecrets(cfg *localAppConfig) error {
+	token, err := c.open(cfg.Github.AccessToken)
+	if err != nil {
+		return err
+	}
+	cfg.Github.AccessToken = token
+
+	if cfg.S3 != nil {
+		secretKey, err := c.open(cfg.S3.SecretAccessKey)
+		if err != nil {
+			return err
+		}
+		cfg.S3.SecretAccessKey = secretKey
+	}
+	if cfg.SFTP != nil {
+		passphrase, err := c.open(cfg.SFTP.KeyPassphrase)
+		if err != nil {
+			return err
+		}
+		cfg.SFTP.KeyPassphrase = passphrase
+	}
+	return nil
+}
+
+// sealSecrets はlocalAppConfig内の秘密情報を暗号化する
+func (c *secretCipher) sealSecrets(cfg *localAppConfig) error {
+	token, err := c.seal(cfg.Github.AccessToken)
+	if err != nil {
+		return err
+	}
+	cfg.Github.AccessToken = token
+
+	if cfg.S3 != nil {
+		secretKey, err := c.seal(cfg.S3.SecretAccessKey)
+		if err != nil {
+			return err
+		}
+		cfg.S3.SecretAccessKey = secretKey
+	}
+	if cfg.SFTP != nil {
+		passphrase, err := c.seal(cfg.SFTP.KeyPassphrase)
+		if err != nil {
+			return err
+		}
+		cfg.SFTP.KeyPassphrase = passphrase
+	}
+	return nil
+}
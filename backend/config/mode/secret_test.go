@@ -0,0 +1,112 @@
+package mode
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSecretCipherRoundTrip(t *testing.T) {
+	c := newSecretCipher("passphrase")
+
+	sealed, err := c.seal("ghp_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, encryptedSecretPrefix) || strings.Contains(sealed, "ghp_token") {
+		t.Fatalf("sealed = %q", sealed)
+	}
+	// 同じ値でも暗号文は毎回異なる
+	if again, _ := c.seal("ghp_token"); again == sealed {
+		t.Error("the same ciphertext is produced twice")
+	}
+
+	// 別のプロセスでも同じパスフレーズであれば復号できる
+	for name, opener := range map[string]*secretCipher{"same cipher": c, "new cipher": newSecretCipher("passphrase")} {
+		if got, err := opener.open(sealed); err != nil || got != "ghp_token" {
+			t.Errorf("%s: open = %q, %v, want ghp_token", name, got, err)
+		}
+	}
+}
+
+func TestSecretCipherOpen(t *testing.T) {
+	sealed, err := newSecretCipher("passphrase").seal("ghp_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cipher  *secretCipher
+		value   string
+		want    string
+		wantErr string
+	}{
+		{"plain value without passphrase", newSecretCipher(""), "ghp_plain", "ghp_plain", ""},
+		{"plain value with passphrase", newSecretCipher("passphrase"), "ghp_plain", "ghp_plain", ""},
+		{"encrypted value without passphrase", newSecretCipher(""), sealed, "", ErrPassphraseRequired.Error()},
+		{"wrong passphrase", newSecretCipher("other"), sealed, "", "wrong passphrase"},
+		{"not base64", newSecretCipher("passphrase"), encryptedSecretPrefix + "%%%", "", "corrupted"},
+		{"too short", newSecretCipher("passphrase"), encryptedSecretPrefix + "AAAA", "", "corrupted"},
+		{"tampered", newSecretCipher("passphrase"), sealed[:len(sealed)-4] + "AAAA", "", "wrong passphrase"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cipher.open(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("open = %q, %v, want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("open = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSealSecretsWithoutPassphraseKeepsPlaintext(t *testing.T) {
+	var cfg localAppConfig
+	cfg.Github.AccessToken = "ghp_token"
+	if err := newSecretCipher("").sealSecrets(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Github.AccessToken != "ghp_token" {
+		t.Errorf("access token = %q, want plaintext", cfg.Github.AccessToken)
+	}
+}
+
+func TestSealAndOpenSecrets(t *testing.T) {
+	c := newSecretCipher("passphrase")
+	var cfg localAppConfig
+	cfg.Github.AccessToken = "ghp_token"
+	cfg.S3 = &localS3{Bucket: "docs", SecretAccessKey: "s3-secret"}
+	cfg.SFTP = &localSFTP{Host: "example.com", KeyPassphrase: ""}
+
+	if err := c.sealSecrets(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range map[string]string{"access token": cfg.Github.AccessToken, "secret access key": cfg.S3.SecretAccessKey} {
+		if !strings.HasPrefix(value, encryptedSecretPrefix) {
+			t.Errorf("%s is not encrypted: %q", name, value)
+		}
+	}
+	// 空の値は暗号化しない
+	if cfg.SFTP.KeyPassphrase != "" {
+		t.Errorf("key passphrase = %q, want empty", cfg.SFTP.KeyPassphrase)
+	}
+	if cfg.S3.Bucket != "docs" || cfg.SFTP.Host != "example.com" {
+		t.Errorf("other fields were changed: %+v %+v", cfg.S3, cfg.SFTP)
+	}
+
+	if err := newSecretCipher("").openSecrets(&cfg); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("openSecrets without passphrase = %v, want ErrPassphraseRequired", err)
+	}
+	if err := c.openSecrets(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Github.AccessToken != "ghp_token" || cfg.S3.SecretAccessKey != "s3-secret" || cfg.SFTP.KeyPassphrase != "" {
+		t.Errorf("opened = %q %q %q", cfg.Github.AccessToken, cfg.S3.SecretAccessKey, cfg.SFTP.KeyPassphrase)
+	}
+}
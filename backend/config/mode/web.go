@@ -0,0 +1,173 @@
+package mode
+
+import (
+	"backend/config"
+	"backend/util"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+)
+
+// web は複数ユーザーで共有するサーバー向けの設定プロバイダー
+// ユーザーごとの設定をdataDir/users/<user>.jsonに保存する
+type web struct {
+	dataDir      string
+	allowedRoots []string
+	cipher       *secretCipher
+}
+
+var ErrNoUser = errors.New("no authenticated user in context")
+
+var userNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// NewWebProvider はwebモードの設定プロバイダーを返す
+// allowedRootsはユーザーがドキュメントのディレクトリとして指定できる範囲で、1つ以上必要
+// passphraseを指定した場合は設定ファイル内の秘密情報を暗号化して保存する
+func NewWebProvider(dataDir string, allowedRoots []string, passphrase string) (*web, error) {
+	if len(allowedRoots) == 0 {
+		return nil, errors.New("web mode requires at least one allowed root directory")
+	}
+
+	roots := make([]string, 0, len(allowedRoots))
+	for _, root := range allowedRoots {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, abs)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dataDir, "users"), fs.ModePerm); err != nil {
+		return nil, err
+	}
+
+	return &web{
+		dataDir:      dataDir,
+		allowedRoots: roots,
+		cipher:       newSecretCipher(passphrase),
+	}, nil
+}
+
+var _ config.UserAppConfigProvider = (*web)(nil)
+
+func (p *web) PerUser() bool {
+	return true
+}
+
+// Load はctxのユーザーの設定を返す
+// ユーザーが無い場合（起動時など）はサーバー全体の設定として許可されたディレクトリを返す
+func (p *web) Load(ctx context.Context) (*config.AppConfig, error) {
+	user, ok := config.UserFromContext(ctx)
+	if !ok {
+		return &config.AppConfig{
+			LocalFile: config.LocalFile{
+				Directories:  p.allowedRoots,
+				AllowedRoots: p.allowedRoots,
+			},
+			AppMode: config.Web,
+		}, nil
+	}
+
+	configPath, err := p.userConfigPath(user)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := newLocalAppConfig(&config.AppConfig{})
+	b, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if cfg, _, err = decodeLocalAppConfig(b); err != nil {
+			return nil, fmt.Errorf("failed to load config of user %s: %w", user, err)
+		}
+		if err := p.cipher.openSecrets(&cfg); err != nil {
+			return nil, fmt.Errorf("failed to load config of user %s: %w", user, err)
+		}
+	}
+
+	appConfig := cfg.toAppConfig()
+	// 許可範囲が変更された場合に備えて範囲外のディレクトリは除外する
+	for i := range appConfig.Workspaces {
+		appConfig.Workspaces[i].Directories = p.filterDirectories(appConfig.Workspaces[i].Directories)
+	}
+	appConfig.LocalFile.Directories = p.filterDirectories(appConfig.LocalFile.Directories)
+	appConfig.LocalFile.AllowedRoots = p.allowedRoots
+	// ユーザーの設定からは外部のプログラムを起動させず、任意のホストにも接続させない
+	appConfig.Plugins = nil
+	appConfig.S3 = config.S3{}
+	appConfig.SFTP = config.SFTP{}
+	appConfig.AppMode = config.Web
+	return appConfig, nil
+}
+
+// AppModeは更新しない
+func (p *web) Save(ctx context.Context, appConfig *config.AppConfig) error {
+	user, ok := config.UserFromContext(ctx)
+	if !ok {
+		return ErrNoUser
+	}
+
+	if err := appConfig.Validate(); err != nil {
+		return err
+	}
+	directories := slices.Clone(appConfig.LocalFile.Directories)
+	for _, w := range appConfig.Workspaces {
+		directories = append(directories, w.Directories...)
+	}
+	for _, dir := range directories {
+		if !util.PathWithin(dir, p.allowedRoots) {
+			return &config.ValidationError{
+				Field:   "LocalFile.Directories",
+				Message: fmt.Sprintf("%s is outside of the allowed roots", dir),
+			}
+		}
+	}
+
+	configPath, err := p.userConfigPath(user)
+	if err != nil {
+		return err
+	}
+
+	cfg := newLocalAppConfig(appConfig)
+	cfg.Plugins = nil
+	cfg.S3 = nil
+	cfg.SFTP = nil
+	if err := p.cipher.sealSecrets(&cfg); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := configPath + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, configPath)
+}
+
+func (p *web) filterDirectories(directories []string) []string {
+	filtered := []string{}
+	for _, dir := range directories {
+		if util.PathWithin(dir, p.allowedRoots) {
+			filtered = append(filtered, dir)
+		}
+	}
+	return filtered
+}
+
+func (p *web) userConfigPath(user string) (string, error) {
+	if !userNamePattern.MatchString(user) {
+		return "", fmt.Errorf("invalid user name: %q", user)
+	}
+	return filepath.Join(p.dataDir, "users", user+".json"), nil
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+)
+
+// Plugin は外部のプロバイダーとして起動する実行ファイル
+// RepoKindはプラグイン自身が起動時に通知する
+// 起動時にのみ読み込むため、実行中の設定ファイルの変更は再起動するまで反映されない
+type Plugin struct {
+	Name    string            `json:"name" example:"acme-wiki" doc:"Name of the plugin used in logs"`
+	Command string            `json:"command" example:"/usr/local/bin/repo-wise-acme" doc:"Executable of the plugin"`
+	Args    []string          `json:"args,omitempty" doc:"Arguments passed to the executable"`
+	Env     map[string]Secret `json:"env,omitempty" doc:"Environment variables added for the plugin (values are masked in responses)"`
+}
+
+func validatePlugins(plugins []Plugin) []error {
+	var errs []error
+
+	seen := map[string]bool{}
+	for i, plugin := range plugins {
+		field := fmt.Sprintf("Plugins[%d]", i)
+		if plugin.Name == "" {
+			errs = append(errs, &ValidationError{Field: field + ".Name", Message: "must not be empty"})
+		}
+		if seen[plugin.Name] {
+			errs = append(errs, &ValidationError{Field: field + ".Name", Message: fmt.Sprintf("%s is used more than once", plugin.Name)})
+		}
+		seen[plugin.Name] = true
+		if plugin.Command == "" {
+			errs = append(errs, &ValidationError{Field: field + ".Command", Message: "must not be empty"})
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+// S3 はS3互換のオブジェクトストレージをドキュメントの取得元として使う場合の設定
+// Bucket配下のPrefixをドキュメントのルートとして扱う
+type S3 struct {
+	Endpoint        string `json:",omitempty" example:"s3.amazonaws.com" doc:"Endpoint host (and port) of the S3 compatible storage"`
+	Region          string `json:",omitempty" example:"ap-northeast-1"`
+	Bucket          string `json:",omitempty" example:"design-docs"`
+	Prefix          string `json:",omitempty" example:"docs/" doc:"Key prefix treated as the root directory"`
+	AccessKeyID     string `json:",omitempty"`
+	SecretAccessKey Secret `json:",omitempty"`
+	// Insecure はHTTPで接続する（ローカルのS3互換サーバー向け）
+	Insecure bool `json:",omitempty"`
+	// PathStyle はバケット名をホスト名ではなくパスに含める
+	PathStyle bool `json:",omitempty"`
+}
+
+// Configured はS3の取得元が設定されているかを返す
+func (s S3) Configured() bool {
+	return s.Bucket != ""
+}
+
+func validateS3(s S3) []error {
+	if !s.Configured() {
+		return nil
+	}
+
+	var errs []error
+	if s.Endpoint == "" {
+		errs = append(errs, &ValidationError{Field: "S3.Endpoint", Message: "must not be empty"})
+	} else if strings.Contains(s.Endpoint, "/") {
+		// スキームやパスを含めた場合はhostだけを指定するように促す
+		if u, err := url.Parse(s.Endpoint); err == nil && u.Host != "" {
+			errs = append(errs, &ValidationError{Field: "S3.Endpoint", Message: "must be a host without scheme (use " + u.Host + ")"})
+		} else {
+			errs = append(errs, &ValidationError{Field: "S3.Endpoint", Message: "must be a host without scheme or path"})
+		}
+	}
+	if (s.AccessKeyID == "") != (s.SecretAccessKey == "") {
+		errs = append(errs, &ValidationError{Field: "S3.AccessKeyID", Message: "access key ID and secret access key must be set together"})
+	}
+	return errs
+}
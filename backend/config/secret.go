@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// SecretMask はAPIのレスポンスやログで秘密情報の代わりに表示する値
+const SecretMask = "********"
+
+// Secret はログなどに出力されないようにマスクされる文字列
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return SecretMask
+}
+
+func (s Secret) GoString() string {
+	return fmt.Sprintf("%q", s.String())
+}
+
+// LogValue はslogで出力される場合にもマスクする
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// Reveal は秘密情報の実際の値を返す
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// Redacted は秘密情報をマスクしたコピーを返す
+func (c AppConfig) Redacted() AppConfig {
+	c.Github.AccessToken = Secret(c.Github.AccessToken.String())
+	c.S3.SecretAccessKey = Secret(c.S3.SecretAccessKey.String())
+	c.SFTP.KeyPassphrase = Secret(c.SFTP.KeyPassphrase.String())
+	// プラグインの環境変数にはトークンなどが含まれるため値を全てマスクする
+	if c.Plugins != nil {
+		plugins := make([]Plugin, len(c.Plugins))
+		for i, plugin := range c.Plugins {
+			if plugin.Env != nil {
+				env := make(map[string]Secret, len(plugin.Env))
+				for k, v := range plugin.Env {
+					env[k] = Secret(v.String())
+				}
+				plugin.Env = env
+			}
+			plugins[i] = plugin
+		}
+		c.Plugins = plugins
+	}
+	return c
+}
+
+// MergeSecrets はマスクされたままの秘密情報をcurrentの値で置き換える
+// APIから受け取った設定を保存する前に使用し、秘密情報を書き込み専用として扱う
+func (c *AppConfig) MergeSecrets(current *AppConfig) {
+	if c.Github.AccessToken == SecretMask {
+		c.Github.AccessToken = current.Github.AccessToken
+	}
+	if c.S3.SecretAccessKey == SecretMask {
+		c.S3.SecretAccessKey = current.S3.SecretAccessKey
+	}
+	if c.SFTP.KeyPassphrase == SecretMask {
+		c.SFTP.KeyPassphrase = current.SFTP.KeyPassphrase
+	}
+}
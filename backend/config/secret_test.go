@@ -0,0 +1,64 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSecretIsMaskedInOutput(t *testing.T) {
+	secret := Secret("ghp_token")
+
+	var logs bytes.Buffer
+	slog.New(slog.NewTextHandler(&logs, nil)).Info("loaded", "token", secret)
+
+	outputs := map[string]string{
+		"String":       secret.String(),
+		"%v":           fmt.Sprintf("%v", secret),
+		"%s":           fmt.Sprintf("%s", secret),
+		"%#v":          fmt.Sprintf("%#v", secret),
+		"slog":         logs.String(),
+		"struct field": fmt.Sprintf("%+v", struct{ Token Secret }{secret}),
+	}
+	for name, output := range outputs {
+		if strings.Contains(output, "ghp_token") || !strings.Contains(output, SecretMask) {
+			t.Errorf("%s = %q, want masked", name, output)
+		}
+	}
+	if secret.Reveal() != "ghp_token" {
+		t.Errorf("Reveal() = %q", secret.Reveal())
+	}
+	if Secret("").String() != "" {
+		t.Errorf("empty secret = %q, want empty", Secret("").String())
+	}
+}
+
+func TestRedactedAndMergeSecrets(t *testing.T) {
+	current := AppConfig{}
+	current.Github.AccessToken = "ghp_token"
+	current.S3.SecretAccessKey = "s3-secret"
+	current.SFTP.KeyPassphrase = ""
+	current.Plugins = []Plugin{{Env: map[string]Secret{"API_KEY": "plugin-secret"}}}
+
+	redacted := current.Redacted()
+	if redacted.Github.AccessToken != SecretMask || redacted.S3.SecretAccessKey != SecretMask || redacted.SFTP.KeyPassphrase != "" {
+		t.Errorf("redacted = %q %q %q", redacted.Github.AccessToken, redacted.S3.SecretAccessKey, redacted.SFTP.KeyPassphrase)
+	}
+	if got := redacted.Plugins[0].Env["API_KEY"]; got != SecretMask {
+		t.Errorf("plugin env = %q, want masked", got)
+	}
+	// 元の設定は変更しない
+	if current.Github.AccessToken != "ghp_token" || current.Plugins[0].Env["API_KEY"] != "plugin-secret" {
+		t.Error("Redacted changed the original config")
+	}
+
+	// マスクされたままの値は現在の値に戻し、変更された値はそのまま使う
+	updated := redacted
+	updated.S3.SecretAccessKey = "new-secret"
+	updated.MergeSecrets(&current)
+	if updated.Github.AccessToken != "ghp_token" || updated.S3.SecretAccessKey != "new-secret" {
+		t.Errorf("merged = %q %q", updated.Github.AccessToken, updated.S3.SecretAccessKey)
+	}
+}
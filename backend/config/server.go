@@ -2,13 +2,27 @@ package config
 
 import (
 	"backend/util"
+	"fmt"
+	"net"
 	"strconv"
+	"time"
 )
 
 type ServerConfig struct {
 	Port int
 	Host string
 	Env  string
+
+	// TLSCertFileとTLSKeyFileが指定された場合はHTTPSで待ち受ける
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSSelfSigned は証明書が指定されていない場合に自己署名証明書を生成してHTTPSで待ち受ける
+	TLSSelfSigned bool
+
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
 }
 
 func NewServerConfig() (*ServerConfig, error) {
@@ -19,9 +33,65 @@ func NewServerConfig() (*ServerConfig, error) {
 	}
 	env := util.LookupEnvOr("ENV", "dev")
 
-	return &ServerConfig{
-		Port: port,
-		Host: "localhost",
-		Env:  env,
-	}, nil
+	tlsSelfSigned, err := strconv.ParseBool(util.LookupEnvOr("TLS_SELF_SIGNED", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS_SELF_SIGNED: %w", err)
+	}
+
+	serverConfig := &ServerConfig{
+		Port:          port,
+		Host:          util.LookupEnvOr("HOST", "localhost"),
+		Env:           env,
+		TLSCertFile:   util.LookupEnvOr("TLS_CERT_FILE", ""),
+		TLSKeyFile:    util.LookupEnvOr("TLS_KEY_FILE", ""),
+		TLSSelfSigned: tlsSelfSigned,
+	}
+	if (serverConfig.TLSCertFile == "") != (serverConfig.TLSKeyFile == "") {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	timeouts := []struct {
+		key   string
+		value string
+		dest  *time.Duration
+	}{
+		{"READ_TIMEOUT", "30s", &serverConfig.ReadTimeout},
+		// 大きなドキュメントの保存や一覧の取得に時間がかかる場合があるため長めにする
+		{"WRITE_TIMEOUT", "60s", &serverConfig.WriteTimeout},
+		{"IDLE_TIMEOUT", "120s", &serverConfig.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", "15s", &serverConfig.ShutdownTimeout},
+	}
+	for _, t := range timeouts {
+		d, err := time.ParseDuration(util.LookupEnvOr(t.key, t.value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", t.key, err)
+		}
+		*t.dest = d
+	}
+
+	return serverConfig, nil
+}
+
+// TLSEnabled はHTTPSで待ち受けるかを返す
+func (c *ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" || c.TLSSelfSigned
+}
+
+// BaseURL はブラウザからアクセスするためのURLを返す
+func (c *ServerConfig) BaseURL() string {
+	scheme := "http"
+	if c.TLSEnabled() {
+		scheme = "https"
+	}
+	host := c.Host
+	// 全てのインターフェースで待ち受ける場合はlocalhostで案内する
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, strconv.Itoa(c.Port)))
+}
+
+// Addr は待ち受けるアドレスを返す
+func (c *ServerConfig) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
 }
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"net"
+	"path"
+)
+
+// SFTP はSSHで接続できるリモートマシンのディレクトリをドキュメントの取得元として使う場合の設定
+// ドキュメントのパスはリモートマシン上の絶対パスで表す
+type SFTP struct {
+	Host          string `json:",omitempty" example:"docs.example.com:22" doc:"Host (and port) of the SSH server"`
+	User          string `json:",omitempty" example:"deploy"`
+	KeyPath       string `json:",omitempty" example:"~/.ssh/id_ed25519" doc:"Path to the private key used for authentication"`
+	KeyPassphrase Secret `json:",omitempty" doc:"Passphrase of the private key, if encrypted"`
+	// KnownHostsPath が空の場合は~/.ssh/known_hostsを使う
+	KnownHostsPath string `json:",omitempty" example:"~/.ssh/known_hosts" doc:"Path to the known_hosts file used to verify the host key"`
+	// Root の外のパスは読み書きしない
+	Root string `json:",omitempty" example:"/srv/docs" doc:"Absolute path of the remote directory documents are read from and written to"`
+}
+
+// Configured はSFTPの取得元が設定されているかを返す
+func (s SFTP) Configured() bool {
+	return s.Host != ""
+}
+
+// Addr はポートを省略した場合に22番ポートを補ったアドレスを返す
+func (s SFTP) Addr() string {
+	if _, _, err := net.SplitHostPort(s.Host); err == nil {
+		return s.Host
+	}
+	return net.JoinHostPort(s.Host, "22")
+}
+
+func validateSFTP(s SFTP) []error {
+	if !s.Configured() {
+		return nil
+	}
+
+	var errs []error
+	if s.User == "" {
+		errs = append(errs, &ValidationError{Field: "SFTP.User", Message: "must not be empty"})
+	}
+	if s.KeyPath == "" {
+		errs = append(errs, &ValidationError{Field: "SFTP.KeyPath", Message: "must not be empty"})
+	}
+	if s.Root == "" {
+		errs = append(errs, &ValidationError{Field: "SFTP.Root", Message: "must not be empty"})
+	} else if !path.IsAbs(s.Root) {
+		errs = append(errs, &ValidationError{Field: "SFTP.Root", Message: "must be an absolute path"})
+	}
+	return errs
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"backend/metrics"
+	"context"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// WatchableAppConfigProvider は設定の変更を検知できるAppConfigProvider
+type WatchableAppConfigProvider interface {
+	AppConfigProvider
+	// ModTime は設定が最後に変更された時刻を返す
+	ModTime() (time.Time, error)
+}
+
+// UserAppConfigProvider はユーザーごとに設定を保持するAppConfigProvider（webモード）
+type UserAppConfigProvider interface {
+	AppConfigProvider
+	// PerUser はctxのユーザーごとに設定を読み書きする場合にtrueを返す
+	PerUser() bool
+}
+
+// Store は読み込み済みの設定を保持し、変更があれば再読み込みして購読者に通知する
+// 再読み込みに失敗した場合は直前の正しい設定を使い続ける
+// プラグインは起動時に開始するため、変更されても再起動するまでは起動時のものを使い続ける
+type Store struct {
+	provider AppConfigProvider
+	// perUser はユーザーごとの設定を毎回プロバイダーから読み書きするか
+	perUser bool
+
+	mu          sync.RWMutex
+	current     *AppConfig
+	modTime     time.Time
+	lastErr     error
+	subscribers []func(*AppConfig)
+	// running は起動時に開始したプラグイン
+	running []Plugin
+	// pendingPlugins は設定ファイルに書かれた、再起動後に反映されるプラグイン
+	pendingPlugins  []Plugin
+	restartRequired bool
+}
+
+var _ AppConfigProvider = (*Store)(nil)
+
+// loadErrは起動時の読み込みのエラーで、次に再読み込みに成功するまでLastErrorで返される
+func NewStore(provider AppConfigProvider, initial *AppConfig, loadErr error) *Store {
+	userProvider, ok := provider.(UserAppConfigProvider)
+	var running []Plugin
+	if initial != nil {
+		running = initial.Plugins
+	}
+	return &Store{
+		provider: provider,
+		perUser:  ok && userProvider.PerUser(),
+		current:  initial,
+		lastErr:  loadErr,
+		running:  running,
+	}
+}
+
+// Current は最後に読み込みに成功した設定を返す
+func (s *Store) Current() *AppConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// LastError は直前の読み込みのエラーを返す
+func (s *Store) LastError() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastErr
+}
+
+// RestartRequired は再起動しないと反映されない変更（プラグイン）が設定にあるかを返す
+func (s *Store) RestartRequired() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.restartRequired
+}
+
+// Subscribe は設定が変更されたときに呼ばれる関数を登録する
+func (s *Store) Subscribe(fn func(*AppConfig)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Load はユーザーごとの設定であればプロバイダーから、それ以外は保持している設定を返す
+func (s *Store) Load(ctx context.Context) (*AppConfig, error) {
+	if s.userScoped(ctx) {
+		return s.provider.Load(ctx)
+	}
+	return s.Current(), nil
+}
+
+// AppModeは更新しない
+// ユーザーごとの設定以外は保存した後に読み込み直して購読者に通知する
+func (s *Store) Save(ctx context.Context, appConfig *AppConfig) error {
+	if !s.userScoped(ctx) {
+		appConfig = s.withPendingPlugins(appConfig)
+	}
+	if err := s.provider.Save(ctx, appConfig); err != nil {
+		return err
+	}
+	if s.userScoped(ctx) {
+		return nil
+	}
+	return s.Reload(ctx)
+}
+
+// userScoped はctxのユーザーの設定を読み書きするかを返す
+// 認証されたユーザーがいても、ユーザーごとの設定を持たないプロバイダーでは全体の設定を使う
+func (s *Store) userScoped(ctx context.Context) bool {
+	if !s.perUser {
+		return false
+	}
+	_, ok := UserFromContext(ctx)
+	return ok
+}
+
+// Reload は設定を読み込み直し、成功した場合は購読者に通知する
+func (s *Store) Reload(ctx context.Context) error {
+	modTime := s.providerModTime()
+
+	appConfig, err := s.provider.Load(ctx)
+	if err == nil {
+		err = appConfig.Validate()
+	}
+	metrics.ConfigReload(err)
+
+	s.mu.Lock()
+	s.modTime = modTime
+	s.lastErr = err
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	// プラグイン以外の変更は反映し、プラグインは起動時のものを使い続ける
+	restartRequired := !samePlugins(s.running, appConfig.Plugins)
+	if restartRequired && !s.restartRequired {
+		log.Println("Plugins were changed; restart the server to apply them")
+	}
+	s.restartRequired = restartRequired
+	s.pendingPlugins = nil
+	if restartRequired {
+		s.pendingPlugins = appConfig.Plugins
+		applied := *appConfig
+		applied.Plugins = s.running
+		appConfig = &applied
+	}
+	s.current = appConfig
+	subscribers := append([]func(*AppConfig){}, s.subscribers...)
+	s.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(appConfig)
+	}
+	return nil
+}
+
+// Watch はintervalごとに設定の変更を確認し、変更があれば再読み込みする
+// ctxがキャンセルされるまでブロックする
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	if _, ok := s.provider.(WatchableAppConfigProvider); !ok {
+		return
+	}
+
+	s.mu.Lock()
+	if s.modTime.IsZero() {
+		s.modTime = s.providerModTime()
+	}
+	s.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modTime := s.providerModTime()
+		s.mu.RLock()
+		changed := !modTime.Equal(s.modTime)
+		s.mu.RUnlock()
+		if !changed {
+			continue
+		}
+
+		if err := s.Reload(ctx); err != nil {
+			log.Printf("Rejected configuration change, keeping the previous one: %v", err)
+			continue
+		}
+		log.Println("Configuration reloaded")
+	}
+}
+
+// withPendingPlugins は起動時のプラグインのまま保存しようとしている場合に、設定ファイルに書かれたプラグインに戻す
+// 再起動を待っているプラグインの変更が、他の設定の保存で失われないようにする
+func (s *Store) withPendingPlugins(appConfig *AppConfig) *AppConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.restartRequired || !samePlugins(appConfig.Plugins, s.running) {
+		return appConfig
+	}
+	saved := *appConfig
+	saved.Plugins = s.pendingPlugins
+	return &saved
+}
+
+func samePlugins(a, b []Plugin) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func (s *Store) providerModTime() time.Time {
+	watchable, ok := s.provider.(WatchableAppConfigProvider)
+	if !ok {
+		return time.Time{}
+	}
+	modTime, err := watchable.ModTime()
+	if err != nil {
+		return time.Time{}
+	}
+	return modTime
+}
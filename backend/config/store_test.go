@@ -0,0 +1,145 @@
+package config
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type memoryProvider struct {
+	appConfig *AppConfig
+}
+
+func (p *memoryProvider) Load(ctx context.Context) (*AppConfig, error) {
+	return p.appConfig, nil
+}
+
+func (p *memoryProvider) Save(ctx context.Context, appConfig *AppConfig) error {
+	p.appConfig = appConfig
+	return nil
+}
+
+func TestReloadKeepsRunningPlugins(t *testing.T) {
+	running := []Plugin{{Name: "wiki", Command: "/bin/wiki"}}
+	initial := &AppConfig{AppMode: CLI, Plugins: running}
+	provider := &memoryProvider{appConfig: initial}
+	store := NewStore(provider, initial, nil)
+
+	notified := 0
+	store.Subscribe(func(*AppConfig) { notified++ })
+
+	// プラグインと同時に変更した他の設定は反映し、プラグインは起動時のものを使い続ける
+	edited := []Plugin{{Name: "wiki", Command: "/bin/other"}}
+	changed := &AppConfig{AppMode: CLI, Plugins: edited}
+	changed.Github.IgnoreRepos = []string{"owner/repo"}
+	provider.appConfig = changed
+	if err := store.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() = %v", err)
+	}
+	current := store.Current()
+	if notified != 1 || len(current.Github.IgnoreRepos) != 1 {
+		t.Errorf("changes other than plugins were not applied")
+	}
+	if !reflect.DeepEqual(current.Plugins, running) {
+		t.Errorf("plugins = %v, want the running ones %v", current.Plugins, running)
+	}
+	if !store.RestartRequired() || store.LastError() != nil {
+		t.Errorf("RestartRequired() = %v, LastError() = %v", store.RestartRequired(), store.LastError())
+	}
+
+	// 続く変更も反映され、保存しても設定ファイルのプラグインは失われない
+	updated := *current
+	updated.Github.IgnoreRepos = nil
+	if err := store.Save(context.Background(), &updated); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(provider.appConfig.Plugins, edited) {
+		t.Errorf("saved plugins = %v, want the edited ones %v", provider.appConfig.Plugins, edited)
+	}
+	if notified != 2 || len(store.Current().Github.IgnoreRepos) != 0 || !store.RestartRequired() {
+		t.Errorf("save after a plugin change was not applied")
+	}
+
+	// 元に戻せば再起動は不要になる
+	provider.appConfig = &AppConfig{AppMode: CLI, Plugins: running}
+	if err := store.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if store.RestartRequired() {
+		t.Errorf("RestartRequired() = true after reverting the plugins")
+	}
+}
+
+// userProvider はユーザーごとに設定を保持するプロバイダー
+type userProvider struct {
+	memoryProvider
+	users map[string]*AppConfig
+}
+
+func (p *userProvider) PerUser() bool {
+	return true
+}
+
+func (p *userProvider) Load(ctx context.Context) (*AppConfig, error) {
+	if user, ok := UserFromContext(ctx); ok {
+		return p.users[user], nil
+	}
+	return p.memoryProvider.Load(ctx)
+}
+
+func (p *userProvider) Save(ctx context.Context, appConfig *AppConfig) error {
+	if user, ok := UserFromContext(ctx); ok {
+		p.users[user] = appConfig
+		return nil
+	}
+	return p.memoryProvider.Save(ctx, appConfig)
+}
+
+func TestStoreWithAuthenticatedUser(t *testing.T) {
+	ctx := WithUser(context.Background(), "owner")
+
+	t.Run("shared configuration", func(t *testing.T) {
+		initial := &AppConfig{AppMode: CLI}
+		provider := &memoryProvider{appConfig: initial}
+		store := NewStore(provider, initial, nil)
+		notified := 0
+		store.Subscribe(func(*AppConfig) { notified++ })
+
+		// 検証前の設定をプロバイダーから直接読まずに、保持している設定を返す
+		provider.appConfig = &AppConfig{AppMode: CLI, Plugins: []Plugin{{Name: "", Command: ""}}}
+		if got, err := store.Load(ctx); err != nil || got != initial {
+			t.Errorf("Load() = %v, %v, want the current configuration", got, err)
+		}
+
+		saved := &AppConfig{AppMode: CLI}
+		saved.Github.IgnoreRepos = []string{"owner/repo"}
+		if err := store.Save(ctx, saved); err != nil {
+			t.Fatal(err)
+		}
+		if store.Current() != saved || notified != 1 {
+			t.Errorf("saved configuration was not applied: current=%v notified=%d", store.Current(), notified)
+		}
+	})
+
+	t.Run("per-user configuration", func(t *testing.T) {
+		initial := &AppConfig{AppMode: Web}
+		provider := &userProvider{memoryProvider: memoryProvider{appConfig: initial}, users: map[string]*AppConfig{}}
+		store := NewStore(provider, initial, nil)
+		notified := 0
+		store.Subscribe(func(*AppConfig) { notified++ })
+
+		saved := &AppConfig{AppMode: Web}
+		if err := store.Save(ctx, saved); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := store.Load(ctx); err != nil || got != saved {
+			t.Errorf("Load() = %v, %v, want the user's configuration", got, err)
+		}
+		if got, _ := store.Load(context.Background()); got != initial {
+			t.Errorf("Load() without user = %v, want the shared configuration", got)
+		}
+		if store.Current() != initial || notified != 0 {
+			t.Errorf("user's configuration replaced the shared one")
+		}
+	})
+}
@@ -0,0 +1,16 @@
+package config
+
+import "context"
+
+type userKey struct{}
+
+// WithUser は認証済みのユーザー名をctxに設定する
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext は認証済みのユーザー名を返す
+func UserFromContext(ctx context.Context) (string, bool) {
+	user, ok := ctx.Value(userKey{}).(string)
+	return user, ok && user != ""
+}
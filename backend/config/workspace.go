@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// 設定にワークスペースが無い場合に作成されるワークスペース名
+const DefaultWorkspaceName = "default"
+
+// Workspace はディレクトリやリポジトリなど、まとめて扱うドキュメントの集合
+type Workspace struct {
+	Name        string            `json:"name" example:"work" doc:"Workspace name"`
+	Directories []string          `json:"directories,omitempty" doc:"Local directories included in the workspace"`
+	GithubRepos []string          `json:"github_repos,omitempty" doc:"GitHub repositories (owner/name) included in the workspace"`
+	IgnoreRepos []string          `json:"ignore_repos,omitempty" doc:"GitHub repositories to ignore"`
+	Discovery   DiscoveryRules    `json:"discovery,omitzero" doc:"Rules to discover documents"`
+	Lint        LintRules         `json:"lint,omitzero" doc:"Markdown lint rules of the workspace"`
+	UIPrefs     map[string]string `json:"ui_prefs,omitempty" doc:"UI preferences of the workspace"`
+}
+
+// DiscoveryRules はドキュメントとして扱うファイルの条件
+// 空の場合は既定の条件を使用する
+type DiscoveryRules struct {
+	IncludeExts     []string `json:"include_exts,omitempty" example:"[\"md\"]" doc:"File extensions to include"`
+	ExcludeDirNames []string `json:"exclude_dir_names,omitempty" example:"[\"node_modules\"]" doc:"Directory names to skip"`
+}
+
+func (r DiscoveryRules) IsZero() bool {
+	return len(r.IncludeExts) == 0 && len(r.ExcludeDirNames) == 0
+}
+
+// ActiveWorkspace は現在のワークスペースを返す（存在しない場合はnil）
+func (c *AppConfig) ActiveWorkspace() *Workspace {
+	for i := range c.Workspaces {
+		if c.Workspaces[i].Name == c.ActiveWorkspaceName {
+			return &c.Workspaces[i]
+		}
+	}
+	return nil
+}
+
+// FindWorkspace は名前が一致するワークスペースのインデックスを返す（存在しない場合は-1）
+func (c *AppConfig) FindWorkspace(name string) int {
+	return slices.IndexFunc(c.Workspaces, func(w Workspace) bool {
+		return w.Name == name
+	})
+}
+
+// ApplyActiveWorkspace は現在のワークスペースの内容をLocalFileとGithubに反映する
+// 既存のAPIはLocalFileとGithubを現在のワークスペースの内容として扱う
+func (c *AppConfig) ApplyActiveWorkspace() {
+	w := c.ActiveWorkspace()
+	if w == nil {
+		return
+	}
+	c.LocalFile.Directories = w.Directories
+	c.Github.IgnoreRepos = w.IgnoreRepos
+}
+
+// SyncActiveWorkspace はLocalFileとGithubの内容を現在のワークスペースに書き戻す
+// ワークスペースが無い場合は既定のワークスペースを作成する
+func (c *AppConfig) SyncActiveWorkspace() {
+	if len(c.Workspaces) == 0 {
+		c.Workspaces = []Workspace{{Name: DefaultWorkspaceName}}
+	}
+	if c.ActiveWorkspaceName == "" {
+		c.ActiveWorkspaceName = c.Workspaces[0].Name
+	}
+
+	w := c.ActiveWorkspace()
+	if w == nil {
+		return
+	}
+	w.Directories = c.LocalFile.Directories
+	w.IgnoreRepos = c.Github.IgnoreRepos
+}
+
+func validateWorkspaces(c *AppConfig) []error {
+	var errs []error
+
+	seen := map[string]bool{}
+	for i, w := range c.Workspaces {
+		field := fmt.Sprintf("Workspaces[%d]", i)
+		if w.Name == "" {
+			errs = append(errs, &ValidationError{Field: field + ".Name", Message: "must not be empty"})
+		}
+		if seen[w.Name] {
+			errs = append(errs, &ValidationError{Field: field + ".Name", Message: fmt.Sprintf("%s is used more than once", w.Name)})
+		}
+		seen[w.Name] = true
+		errs = append(errs, validateDirectories(field+".Directories", w.Directories)...)
+		errs = append(errs, validateLintRules(field+".Lint", w.Lint)...)
+	}
+
+	if len(c.Workspaces) > 0 && c.ActiveWorkspace() == nil {
+		errs = append(errs, &ValidationError{
+			Field:   "ActiveWorkspaceName",
+			Message: fmt.Sprintf("workspace %q does not exist", c.ActiveWorkspaceName),
+		})
+	}
+
+	return errs
+}
@@ -0,0 +1,19 @@
+package demo
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// docs はAPP_MODE=demoで表示するサンプルのドキュメント
+//
+//go:embed all:docs
+var docs embed.FS
+
+// Root はサンプルのドキュメントを配置するディレクトリ
+const Root = "/demo"
+
+// Docs はサンプルのドキュメントをdocsディレクトリをルートとして返す
+func Docs() (fs.FS, error) {
+	return fs.Sub(docs, "docs")
+}
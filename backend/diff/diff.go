@@ -0,0 +1,302 @@
+package diff
+
+import (
+	"slices"
+	"strings"
+)
+
+// Op は差分の1行（または1単語）の種類
+type Op string
+
+const (
+	OpEqual  Op = "equal"
+	OpInsert Op = "insert"
+	OpDelete Op = "delete"
+)
+
+// maxEditDistance は最短の編集を探す上限
+// 超えた場合は残りの範囲を全て削除と追加として扱い、メモリの使用量を抑える
+const maxEditDistance = 2000
+
+// Segment は行の中の単語単位の差分
+type Segment struct {
+	Op   Op     `json:"op" enum:"equal,insert,delete" doc:"Whether the text is unchanged, inserted or deleted"`
+	Text string `json:"text" doc:"Text of the segment"`
+}
+
+// Line は差分の1行
+type Line struct {
+	Op        Op        `json:"op" enum:"equal,insert,delete" doc:"Whether the line is unchanged, inserted or deleted"`
+	OldLine   int       `json:"old_line,omitempty" doc:"1-based line number in the old text (not set for inserted lines)"`
+	NewLine   int       `json:"new_line,omitempty" doc:"1-based line number in the new text (not set for deleted lines)"`
+	Text      string    `json:"text" doc:"Content of the line without the newline (a carriage return of CRLF is kept)"`
+	NoNewline bool      `json:"no_newline,omitempty" doc:"Whether the line is the last line and has no newline at the end"`
+	Words     []Segment `json:"words,omitempty" doc:"Word-level changes against the paired deleted or inserted line"`
+}
+
+// Hunk は変更された行とその前後の行のまとまり
+type Hunk struct {
+	OldStart int    `json:"old_start" doc:"1-based first line of the hunk in the old text"`
+	OldLines int    `json:"old_lines" doc:"Number of lines of the old text in the hunk"`
+	NewStart int    `json:"new_start" doc:"1-based first line of the hunk in the new text"`
+	NewLines int    `json:"new_lines" doc:"Number of lines of the new text in the hunk"`
+	Lines    []Line `json:"lines" doc:"Lines of the hunk"`
+}
+
+// Lines はoldとnewを行単位で比較し、前後にcontext行を含むまとまりを返す
+// 削除された行の直後に追加された行は順に対応付け、単語単位の差分も付ける
+func Lines(oldText, newText string, context int) []Hunk {
+	oldLines, newLines := splitLines(oldText), splitLines(newText)
+	lines := toLines(compute(oldLines, newLines), oldLines, newLines)
+	addWordDiffs(lines)
+	return group(lines, context)
+}
+
+// Count は追加された行と削除された行の数を返す
+func Count(hunks []Hunk) (added int, deleted int) {
+	for _, h := range hunks {
+		for _, l := range h.Lines {
+			switch l.Op {
+			case OpInsert:
+				added++
+			case OpDelete:
+				deleted++
+			}
+		}
+	}
+	return added, deleted
+}
+
+// splitLines は改行を含めた行に分ける
+// 改行の有無や改行コードだけの変更も差分になるよう、比較には改行を含めた行を使う
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// newLine は改行を含めた行からLineを作る
+func newLine(op Op, oldLine, newLine int, text string) Line {
+	content, ok := strings.CutSuffix(text, "\n")
+	return Line{Op: op, OldLine: oldLine, NewLine: newLine, Text: content, NoNewline: !ok}
+}
+
+type edit struct {
+	op       Op
+	oldIndex int
+	newIndex int
+}
+
+// compute はMyersのアルゴリズムでaをbに変える最短の編集を返す
+func compute[T comparable](a, b []T) []edit {
+	// 共通の先頭と末尾は比較の対象から外す
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []edit
+	for i := range prefix {
+		edits = append(edits, edit{OpEqual, i, i})
+	}
+	for _, e := range middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		e.oldIndex += prefix
+		e.newIndex += prefix
+		edits = append(edits, e)
+	}
+	for i := suffix; i > 0; i-- {
+		edits = append(edits, edit{OpEqual, len(a) - i, len(b) - i})
+	}
+	return edits
+}
+
+func middle[T comparable](a, b []T) []edit {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEditDistance)
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	// trace[d]はd回目の探索を始める前のvのうち、-dからdの範囲
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+	}
+
+	// 上限を超えた場合は全て置き換える
+	edits := make([]edit, 0, n+m)
+	for i := range n {
+		edits = append(edits, edit{OpDelete, i, 0})
+	}
+	for j := range m {
+		edits = append(edits, edit{OpInsert, n, j})
+	}
+	return edits
+}
+
+func backtrack(trace [][]int, n, m int) []edit {
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || k != d && at(k-1) < at(k+1) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{OpEqual, x, y})
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, edit{OpInsert, x, y})
+		} else {
+			x--
+			edits = append(edits, edit{OpDelete, x, y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		edits = append(edits, edit{OpEqual, x, y})
+	}
+	slices.Reverse(edits)
+	return edits
+}
+
+func toLines(edits []edit, oldLines, newLines []string) []Line {
+	lines := make([]Line, len(edits))
+	for i, e := range edits {
+		switch e.op {
+		case OpEqual:
+			lines[i] = newLine(OpEqual, e.oldIndex+1, e.newIndex+1, oldLines[e.oldIndex])
+		case OpDelete:
+			lines[i] = newLine(OpDelete, e.oldIndex+1, 0, oldLines[e.oldIndex])
+		case OpInsert:
+			lines[i] = newLine(OpInsert, 0, e.newIndex+1, newLines[e.newIndex])
+		}
+	}
+	return lines
+}
+
+// addWordDiffs は連続する削除行と追加行を先頭から順に対応付け、単語単位の差分を付ける
+func addWordDiffs(lines []Line) {
+	for i := 0; i < len(lines); {
+		if lines[i].Op != OpDelete {
+			i++
+			continue
+		}
+		deleteStart := i
+		for i < len(lines) && lines[i].Op == OpDelete {
+			i++
+		}
+		insertStart := i
+		for i < len(lines) && lines[i].Op == OpInsert {
+			i++
+		}
+		pairs := min(insertStart-deleteStart, i-insertStart)
+		for p := range pairs {
+			oldLine, newLine := &lines[deleteStart+p], &lines[insertStart+p]
+			oldLine.Words, newLine.Words = Words(oldLine.Text, newLine.Text)
+		}
+	}
+}
+
+// group は変更された行の前後context行を含むまとまりに分ける
+// まとまりの間がcontext*2行以下の場合は1つにまとめる
+func group(lines []Line, context int) []Hunk {
+	context = max(context, 0)
+	var hunks []Hunk
+	for i := 0; i < len(lines); {
+		if lines[i].Op == OpEqual {
+			i++
+			continue
+		}
+		start := max(i-context, 0)
+		end := i
+		for end < len(lines) {
+			if lines[end].Op != OpEqual {
+				end++
+				continue
+			}
+			// 次の変更までの変更されていない行の数
+			next := end
+			for next < len(lines) && lines[next].Op == OpEqual {
+				next++
+			}
+			if next == len(lines) || next-end > context*2 {
+				break
+			}
+			end = next
+		}
+		stop := min(end+context, len(lines))
+		hunks = append(hunks, newHunk(lines, start, stop))
+		i = stop
+	}
+	return hunks
+}
+
+func newHunk(lines []Line, start, stop int) Hunk {
+	h := Hunk{Lines: slices.Clone(lines[start:stop])}
+	for _, l := range h.Lines {
+		if l.Op != OpInsert {
+			h.OldLines++
+			if h.OldStart == 0 {
+				h.OldStart = l.OldLine
+			}
+		}
+		if l.Op != OpDelete {
+			h.NewLines++
+			if h.NewStart == 0 {
+				h.NewStart = l.NewLine
+			}
+		}
+	}
+	// 行が無い側は直前の行の番号を開始位置とする（unified形式と同じ）
+	if h.OldLines == 0 {
+		h.OldStart = previousLine(lines[:start], func(l Line) int { return l.OldLine })
+	}
+	if h.NewLines == 0 {
+		h.NewStart = previousLine(lines[:start], func(l Line) int { return l.NewLine })
+	}
+	return h
+}
+
+func previousLine(lines []Line, number func(Line) int) int {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if n := number(lines[i]); n > 0 {
+			return n
+		}
+	}
+	return 0
+}
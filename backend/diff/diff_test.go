@@ -0,0 +1,254 @@
+package diff
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// apply はeditsを順に適用し、aとbを組み立て直す
+func apply[T comparable](t *testing.T, a, b []T, edits []edit) (oldResult, newResult []T) {
+	t.Helper()
+	for _, e := range edits {
+		switch e.op {
+		case OpEqual:
+			if a[e.oldIndex] != b[e.newIndex] {
+				t.Fatalf("equal edit %v pairs different elements", e)
+			}
+			oldResult = append(oldResult, a[e.oldIndex])
+			newResult = append(newResult, b[e.newIndex])
+		case OpDelete:
+			oldResult = append(oldResult, a[e.oldIndex])
+		case OpInsert:
+			newResult = append(newResult, b[e.newIndex])
+		}
+	}
+	return oldResult, newResult
+}
+
+// lcsLength は動的計画法で最長共通部分列の長さを求める
+func lcsLength[T comparable](a, b []T) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				dp[i+1][j+1] = dp[i][j] + 1
+			} else {
+				dp[i+1][j+1] = max(dp[i][j+1], dp[i+1][j])
+			}
+		}
+	}
+	return dp[len(a)][len(b)]
+}
+
+func TestComputeReconstructs(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"both empty", "", ""},
+		{"insert all", "", "abc"},
+		{"delete all", "abc", ""},
+		{"same", "abc", "abc"},
+		{"replace middle", "abcdef", "abXYef"},
+		{"classic", "ABCABBA", "CBABAC"},
+		{"repeated", "aaaa", "aa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkCompute(t, []rune(tt.a), []rune(tt.b))
+		})
+	}
+
+	r := rand.New(rand.NewPCG(1, 2))
+	randomText := func() []rune {
+		text := make([]rune, r.IntN(20))
+		for i := range text {
+			text[i] = rune('a' + r.IntN(4))
+		}
+		return text
+	}
+	for range 500 {
+		checkCompute(t, randomText(), randomText())
+	}
+}
+
+func checkCompute(t *testing.T, a, b []rune) {
+	t.Helper()
+	edits := compute(a, b)
+	oldResult, newResult := apply(t, a, b, edits)
+	if !slices.Equal(oldResult, a) || !slices.Equal(newResult, b) {
+		t.Fatalf("compute(%q, %q) rebuilds %q, %q", string(a), string(b), string(oldResult), string(newResult))
+	}
+	equal := 0
+	for _, e := range edits {
+		if e.op == OpEqual {
+			equal++
+		}
+	}
+	if want := lcsLength(a, b); equal != want {
+		t.Errorf("compute(%q, %q) keeps %d elements, want %d", string(a), string(b), equal, want)
+	}
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		wantOld []Segment
+		wantNew []Segment
+	}{
+		{
+			name:    "words separated by spaces",
+			old:     "the quick fox",
+			new:     "the slow fox",
+			wantOld: []Segment{{OpEqual, "the "}, {OpDelete, "quick"}, {OpEqual, " fox"}},
+			wantNew: []Segment{{OpEqual, "the "}, {OpInsert, "slow"}, {OpEqual, " fox"}},
+		},
+		{
+			name:    "japanese is compared by character",
+			old:     "今日は晴れです",
+			new:     "今日は雨です",
+			wantOld: []Segment{{OpEqual, "今日は"}, {OpDelete, "晴れ"}, {OpEqual, "です"}},
+			wantNew: []Segment{{OpEqual, "今日は"}, {OpInsert, "雨"}, {OpEqual, "です"}},
+		},
+		{
+			name:    "mixed scripts",
+			old:     "Go言語で書く",
+			new:     "Rust言語で書く",
+			wantOld: []Segment{{OpDelete, "Go"}, {OpEqual, "言語で書く"}},
+			wantNew: []Segment{{OpInsert, "Rust"}, {OpEqual, "言語で書く"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOld, gotNew := Words(tt.old, tt.new)
+			if !slices.Equal(gotOld, tt.wantOld) {
+				t.Errorf("old segments = %v, want %v", gotOld, tt.wantOld)
+			}
+			if !slices.Equal(gotNew, tt.wantNew) {
+				t.Errorf("new segments = %v, want %v", gotNew, tt.wantNew)
+			}
+		})
+	}
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		context int
+		want    string
+	}{
+		{
+			name:    "no changes",
+			old:     "a\nb\n",
+			new:     "a\nb\n",
+			context: 3,
+			want:    "",
+		},
+		{
+			name:    "insert with context",
+			old:     "a\nb\nc\n",
+			new:     "a\nb\nx\nc\n",
+			context: 3,
+			want: "--- a/doc.md\n+++ b/doc.md\n" +
+				"@@ -1,3 +1,4 @@\n" +
+				" a\n b\n+x\n c\n",
+		},
+		{
+			name:    "single line ranges omit the count",
+			old:     "a\nb\nc\n",
+			new:     "a\nx\nc\n",
+			context: 0,
+			want: "--- a/doc.md\n+++ b/doc.md\n" +
+				"@@ -2 +2 @@\n" +
+				"-b\n+x\n",
+		},
+		{
+			name:    "empty side starts at the previous line",
+			old:     "a\nb\nc\n",
+			new:     "a\nb\nx\nc\n",
+			context: 0,
+			want: "--- a/doc.md\n+++ b/doc.md\n" +
+				"@@ -2,0 +3 @@\n" +
+				"+x\n",
+		},
+		{
+			name:    "insert into an empty file",
+			old:     "",
+			new:     "a\nb\n",
+			context: 3,
+			want: "--- a/doc.md\n+++ b/doc.md\n" +
+				"@@ -0,0 +1,2 @@\n" +
+				"+a\n+b\n",
+		},
+		{
+			name:    "distant changes are separate hunks",
+			old:     "1\n2\n3\n4\n5\n6\n7\n",
+			new:     "x\n2\n3\n4\n5\n6\ny\n",
+			context: 1,
+			want: "--- a/doc.md\n+++ b/doc.md\n" +
+				"@@ -1,2 +1,2 @@\n" +
+				"-1\n+x\n 2\n" +
+				"@@ -6,2 +6,2 @@\n" +
+				" 6\n-7\n+y\n",
+		},
+		{
+			name:    "newline added at the end",
+			old:     "a\nb",
+			new:     "a\nb\n",
+			context: 3,
+			want: "--- a/doc.md\n+++ b/doc.md\n" +
+				"@@ -1,2 +1,2 @@\n" +
+				" a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name:    "newline removed at the end",
+			old:     "a\n",
+			new:     "a",
+			context: 3,
+			want: "--- a/doc.md\n+++ b/doc.md\n" +
+				"@@ -1 +1 @@\n" +
+				"-a\n+a\n\\ No newline at end of file\n",
+		},
+		{
+			name:    "context line without newline",
+			old:     "a\nb",
+			new:     "x\nb",
+			context: 3,
+			want: "--- a/doc.md\n+++ b/doc.md\n" +
+				"@@ -1,2 +1,2 @@\n" +
+				"-a\n+x\n b\n\\ No newline at end of file\n",
+		},
+		{
+			name:    "line endings changed",
+			old:     "a\r\nb\n",
+			new:     "a\nb\n",
+			context: 0,
+			want: "--- a/doc.md\n+++ b/doc.md\n" +
+				"@@ -1 +1 @@\n" +
+				"-a\r\n+a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("a/doc.md", "b/doc.md", Lines(tt.old, tt.new, tt.context))
+			if got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	added, deleted := Count(Lines("a\nb\nc\n", "a\nx\ny\nc\n", 3))
+	if added != 2 || deleted != 1 {
+		t.Errorf("Count() = %d, %d, want 2, 1", added, deleted)
+	}
+}
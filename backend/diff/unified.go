@@ -0,0 +1,44 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Unified はhunksをunified形式（diff -u、git diff）の文字列にする
+// 差分が無い場合は空文字列を返す
+func Unified(oldName, newName string, hunks []Hunk) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks {
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", unifiedRange(h.OldStart, h.OldLines), unifiedRange(h.NewStart, h.NewLines))
+		for _, l := range h.Lines {
+			switch l.Op {
+			case OpEqual:
+				b.WriteString(" ")
+			case OpDelete:
+				b.WriteString("-")
+			case OpInsert:
+				b.WriteString("+")
+			}
+			b.WriteString(l.Text)
+			b.WriteString("\n")
+			if l.NoNewline {
+				b.WriteString("\\ No newline at end of file\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// unifiedRange は1行の場合は行数を省略する
+func unifiedRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
@@ -0,0 +1,78 @@
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Words はoldとnewを単語単位で比較し、それぞれの行の差分を返す
+// oldの差分は変更されていない部分と削除された部分、newの差分は変更されていない部分と追加された部分からなる
+// 空白で区切らない日本語などは1文字を1単語として扱う
+func Words(oldText, newText string) (oldSegments []Segment, newSegments []Segment) {
+	oldTokens, newTokens := tokenize(oldText), tokenize(newText)
+	for _, e := range compute(oldTokens, newTokens) {
+		switch e.op {
+		case OpEqual:
+			oldSegments = appendSegment(oldSegments, OpEqual, oldTokens[e.oldIndex])
+			newSegments = appendSegment(newSegments, OpEqual, newTokens[e.newIndex])
+		case OpDelete:
+			oldSegments = appendSegment(oldSegments, OpDelete, oldTokens[e.oldIndex])
+		case OpInsert:
+			newSegments = appendSegment(newSegments, OpInsert, newTokens[e.newIndex])
+		}
+	}
+	return oldSegments, newSegments
+}
+
+// appendSegment は直前と同じ種類の場合は前のSegmentにつなげる
+func appendSegment(segments []Segment, op Op, text string) []Segment {
+	if n := len(segments); n > 0 && segments[n-1].Op == op {
+		segments[n-1].Text += text
+		return segments
+	}
+	return append(segments, Segment{Op: op, Text: text})
+}
+
+type tokenClass int
+
+const (
+	classSpace tokenClass = iota
+	classWord
+	// classRune は1文字ずつ分ける文字（記号、漢字、かななど）
+	classRune
+)
+
+// tokenize はtextを空白の連続、単語、1文字ずつの文字に分ける
+func tokenize(text string) []string {
+	var tokens []string
+	var current strings.Builder
+	currentClass := classRune
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+	for _, r := range text {
+		class := classify(r)
+		if class != currentClass || class == classRune {
+			flush()
+		}
+		current.WriteRune(r)
+		currentClass = class
+	}
+	flush()
+	return tokens
+}
+
+func classify(r rune) tokenClass {
+	switch {
+	case unicode.IsSpace(r):
+		return classSpace
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Thai):
+		return classRune
+	case unicode.IsLetter(r), unicode.IsNumber(r), unicode.Is(unicode.Mn, r), r == '_':
+		return classWord
+	}
+	return classRune
+}
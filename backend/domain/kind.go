@@ -1,6 +1,10 @@
 package domain
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
 
 type RepoKind struct {
 	value string
@@ -13,15 +17,49 @@ func (k RepoKind) String() string {
 var (
 	LocalRepoKind  = RepoKind{"local"}
 	GithubRepoKind = RepoKind{"github"}
+	S3RepoKind     = RepoKind{"s3"}
+	SFTPRepoKind   = RepoKind{"sftp"}
+	// ArchiveRepoKind はローカルのzipやtarの中を読み取り専用で参照する
+	ArchiveRepoKind = RepoKind{"archive"}
+)
+
+// プラグインなどが追加するkindとして許可する形式
+var repoKindPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+
+var (
+	repoKindsMu sync.RWMutex
+	repoKinds   = map[string]RepoKind{
+		LocalRepoKind.value:   LocalRepoKind,
+		GithubRepoKind.value:  GithubRepoKind,
+		S3RepoKind.value:      S3RepoKind,
+		SFTPRepoKind.value:    SFTPRepoKind,
+		ArchiveRepoKind.value: ArchiveRepoKind,
+	}
 )
 
+// RegisterRepoKind は組み込み以外のkindを追加する
+// 既に存在するkindは登録できない
+func RegisterRepoKind(value string) (RepoKind, error) {
+	if !repoKindPattern.MatchString(value) {
+		return RepoKind{}, fmt.Errorf("invalid Repokind: %s", value)
+	}
+
+	repoKindsMu.Lock()
+	defer repoKindsMu.Unlock()
+	if _, ok := repoKinds[value]; ok {
+		return RepoKind{}, fmt.Errorf("Repokind already exists: %s", value)
+	}
+	kind := RepoKind{value}
+	repoKinds[value] = kind
+	return kind, nil
+}
+
 func ParseRepoKind(value string) (RepoKind, error) {
-	switch value {
-	case "local":
-		return LocalRepoKind, nil
-	case "github":
-		return GithubRepoKind, nil
-	default:
+	repoKindsMu.RLock()
+	defer repoKindsMu.RUnlock()
+	kind, ok := repoKinds[value]
+	if !ok {
 		return RepoKind{}, fmt.Errorf("invalid Repokind: %s", value)
 	}
+	return kind, nil
 }
@@ -13,13 +13,13 @@ type AppConfigGetOutput struct {
 
 func newAppConfigGetHandler(api huma.API, provider config.AppConfigProvider) {
 	huma.Get(api, "/appconfig", func(ctx context.Context, input *struct{}) (*AppConfigGetOutput, error) {
-		appConfig, err := provider.Load()
+		appConfig, err := provider.Load(ctx)
 		if err != nil {
 			return nil, huma.Error500InternalServerError("Failed to load configuration", err)
 		}
 
 		resp := &AppConfigGetOutput{}
-		resp.Body = *appConfig
+		resp.Body = appConfig.Redacted()
 		return resp, nil
 	})
 }
@@ -3,6 +3,7 @@ package handler
 import (
 	"backend/config"
 	"context"
+	"errors"
 
 	"github.com/danielgtaylor/huma/v2"
 )
@@ -19,7 +20,31 @@ type AppConfigUpdateOutput struct {
 
 func newAppConfigUpdateHandler(api huma.API, provider config.AppConfigProvider) {
 	huma.Put(api, "/appconfig", func(ctx context.Context, input *AppConfigUpdateInput) (*AppConfigUpdateOutput, error) {
-		err := provider.Save(&input.Body)
+		// マスクされたまま送られてきた秘密情報は現在の値を維持する
+		// 現在の設定ファイルが読めない場合は、ユーザーが書いた内容を失わないよう上書きしない
+		current, err := provider.Load(ctx)
+		if err != nil {
+			return nil, huma.Error409Conflict("Current configuration could not be loaded; fix the configuration file before updating it", err)
+		}
+		if _, ok := config.UserFromContext(ctx); !ok {
+			if status := configLoadStatus(provider); status != nil && status.LastError() != nil {
+				return nil, huma.Error409Conflict("Current configuration could not be loaded; fix the configuration file before updating it", status.LastError())
+			}
+		}
+		input.Body.MergeSecrets(current)
+		// プラグインは任意のプログラムを起動できるためAPIからは変更させない
+		input.Body.Plugins = current.Plugins
+		// ワークスペースを含まない設定が送られてきた場合は現在のワークスペースのみ更新する
+		if input.Body.Workspaces == nil {
+			input.Body.Workspaces = current.Workspaces
+			input.Body.ActiveWorkspaceName = current.ActiveWorkspaceName
+		}
+
+		err = provider.Save(ctx, &input.Body)
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, huma.Error400BadRequest("Invalid configuration", err)
+		}
 		if err != nil {
 			return nil, huma.Error500InternalServerError("Failed to save configuration", err)
 		}
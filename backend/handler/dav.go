@@ -0,0 +1,592 @@
+package handler
+
+import (
+	"backend/config"
+	"backend/domain"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/net/webdav"
+)
+
+// WebDAVのパスの接頭辞
+const DAVPrefix = "/dav"
+
+// newDAVHandler は現在のワークスペースをWebDAVで公開する
+// /dav/<取得元の名前>/<取得元からの相対パス> をプロバイダーのドキュメントに対応させる
+// 書き込みはPUT /document/contentなどと同じ処理を通す
+func newDAVHandler(appConfigProvider config.AppConfigProvider, registry *ProviderRegistry, sandbox *pathSandbox) http.Handler {
+	h := &webdav.Handler{
+		Prefix: DAVPrefix,
+		FileSystem: &davFS{
+			appConfigProvider: appConfigProvider,
+			registry:          registry,
+			sandbox:           sandbox,
+		},
+		LockSystem: webdav.NewMemLS(),
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), davCacheKey{}, &davCache{})
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func init() {
+	// chiは標準以外のメソッドを登録しないとルーティングしない
+	for _, method := range []string{"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK"} {
+		chi.RegisterMethod(method)
+	}
+}
+
+type davFS struct {
+	appConfigProvider config.AppConfigProvider
+	registry          *ProviderRegistry
+	sandbox           *pathSandbox
+}
+
+var _ webdav.FileSystem = (*davFS)(nil)
+
+// davTarget はWebDAVのパスに対応するドキュメントの位置
+// rootがnilの場合は取得元の一覧（/dav/）を表す
+type davTarget struct {
+	root *WorkspaceRoot
+	rel  string
+	path string
+}
+
+func (t davTarget) isRoot() bool {
+	return t.root == nil || t.rel == ""
+}
+
+// davCache は1つのリクエストの中で取得したディレクトリの一覧と内容を保持する
+// PROPFINDでは要素ごとにStatが呼ばれるため、同じ一覧や内容を何度も取得しないようにする
+type davCache struct {
+	mu    sync.Mutex
+	dirs  map[string][]fs.FileInfo
+	files map[string]string
+}
+
+type davCacheKey struct{}
+
+// davCacheFrom はリクエストのキャッシュを返す（リクエスト以外から呼ばれた場合はnil）
+func davCacheFrom(ctx context.Context) *davCache {
+	cache, _ := ctx.Value(davCacheKey{}).(*davCache)
+	return cache
+}
+
+func davCacheID(target davTarget) string {
+	return target.root.Kind + "\x00" + target.path
+}
+
+func (c *davCache) dir(target davTarget) ([]fs.FileInfo, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	infos, ok := c.dirs[davCacheID(target)]
+	return infos, ok
+}
+
+func (c *davCache) setDir(target davTarget, infos []fs.FileInfo) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dirs == nil {
+		c.dirs = map[string][]fs.FileInfo{}
+	}
+	c.dirs[davCacheID(target)] = infos
+}
+
+func (c *davCache) file(target davTarget) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	content, ok := c.files[davCacheID(target)]
+	return content, ok
+}
+
+func (c *davCache) setFile(target davTarget, content string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.files == nil {
+		c.files = map[string]string{}
+	}
+	c.files[davCacheID(target)] = content
+}
+
+// clear は書き込みの後に呼び、以降の操作で最新の状態を取得させる
+func (c *davCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dirs = nil
+	c.files = nil
+}
+
+// davRoots は取得元に重複しない名前を付ける
+// ローカルのディレクトリはディレクトリ名、GitHubのリポジトリは owner-name とする
+func davRoots(appConfig *config.AppConfig, registry *ProviderRegistry) map[string]WorkspaceRoot {
+	roots := map[string]WorkspaceRoot{}
+	for _, root := range workspaceRoots(appConfig, registry) {
+		base := strings.ReplaceAll(strings.Trim(root.Path, "/"), "/", "-")
+		if root.Kind == domain.LocalRepoKind.String() {
+			base = filepath.Base(root.Path)
+		}
+		name := base
+		for i := 2; ; i++ {
+			if _, ok := roots[name]; !ok {
+				break
+			}
+			name = base + "-" + strconv.Itoa(i)
+		}
+		roots[name] = root
+	}
+	return roots
+}
+
+func (d *davFS) resolve(ctx context.Context, name string) (davTarget, error) {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		return davTarget{}, nil
+	}
+
+	appConfig, err := d.appConfigProvider.Load(ctx)
+	if err != nil {
+		return davTarget{}, err
+	}
+	rootName, rel, _ := strings.Cut(name, "/")
+	root, ok := davRoots(appConfig, d.registry)[rootName]
+	if !ok {
+		return davTarget{}, os.ErrNotExist
+	}
+
+	target := davTarget{root: &root, rel: rel, path: root.Path}
+	if rel != "" {
+		if root.Kind == domain.LocalRepoKind.String() {
+			target.path = filepath.Join(root.Path, filepath.FromSlash(rel))
+		} else {
+			target.path = path.Join(root.Path, rel)
+		}
+	}
+	return target, nil
+}
+
+// readDir は取得元の一覧、またはディレクトリの内容を返す
+func (d *davFS) readDir(ctx context.Context, target davTarget) ([]fs.FileInfo, error) {
+	if target.root == nil {
+		appConfig, err := d.appConfigProvider.Load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		infos := []fs.FileInfo{}
+		for name := range davRoots(appConfig, d.registry) {
+			infos = append(infos, davFileInfo{name: name, isDir: true})
+		}
+		return infos, nil
+	}
+
+	cache := davCacheFrom(ctx)
+	if infos, ok := cache.dir(target); ok {
+		return infos, nil
+	}
+	provider, kind, err := lookupProvider[DirectoryProvider](ctx, d.registry, target.root.Kind, CapabilityBrowse)
+	if err != nil {
+		return nil, davError(err)
+	}
+	if err := d.sandbox.checkRead(ctx, kind, target.path); err != nil {
+		return nil, davError(err)
+	}
+	items, err := provider.GetDirectory(ctx, target.path)
+	if err != nil {
+		logProviderError(ctx, kind, "GetDirectory", err)
+		return nil, err
+	}
+
+	infos := make([]fs.FileInfo, 0, len(items))
+	for _, item := range items {
+		info := davFileInfo{name: item.Name, isDir: item.IsDir, size: item.Size, modTime: item.ModTime}
+		if !item.IsDir && !item.ModTime.IsZero() {
+			info.etag = modTimeETag(item.ModTime, item.Size)
+		}
+		infos = append(infos, info)
+	}
+	cache.setDir(target, infos)
+	return infos, nil
+}
+
+func (d *davFS) readFile(ctx context.Context, target davTarget) (string, error) {
+	cache := davCacheFrom(ctx)
+	if content, ok := cache.file(target); ok {
+		return content, nil
+	}
+	provider, kind, err := lookupProvider[DocumentContentProvider](ctx, d.registry, target.root.Kind, CapabilityRead)
+	if err != nil {
+		return "", davError(err)
+	}
+	if err := d.sandbox.checkRead(ctx, kind, target.path); err != nil {
+		return "", davError(err)
+	}
+	content, err := provider.GetDocumentContent(ctx, target.path)
+	if err != nil {
+		logProviderError(ctx, kind, "GetDocumentContent", err)
+		return "", err
+	}
+	cache.setFile(target, content)
+	return content, nil
+}
+
+func (d *davFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	target, err := d.resolve(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if target.isRoot() {
+		return davFileInfo{name: path.Base("/" + name), isDir: true}, nil
+	}
+
+	// プロバイダーには単一のファイルの情報を取得する操作が無いため親ディレクトリの一覧から探す
+	// 一覧はリクエストの中で再利用する
+	parent, err := d.resolve(ctx, path.Dir(path.Clean("/"+name)))
+	if err != nil {
+		return nil, err
+	}
+	infos, err := d.readDir(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+	base := path.Base(target.rel)
+	for _, info := range infos {
+		if info.Name() != base {
+			continue
+		}
+		if info.IsDir() || !info.ModTime().IsZero() {
+			return info, nil
+		}
+		// 更新日時を返さないプロバイダーは内容からサイズとETagを求める
+		content, err := d.readFile(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		return davFileInfo{name: base, size: int64(len(content)), etag: contentETag(content)}, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func (d *davFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	target, err := d.resolve(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := d.Stat(ctx, name)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if exists && info.IsDir() {
+		if flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+			return nil, os.ErrPermission
+		}
+		return &davFile{fs: d, ctx: ctx, name: name, target: target, info: info}, nil
+	}
+
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	if !exists {
+		if !writable || flag&os.O_CREATE == 0 || target.isRoot() {
+			return nil, os.ErrNotExist
+		}
+		if !isDocumentPath(target.path) {
+			return nil, os.ErrPermission
+		}
+		if err := createDocument(ctx, d.registry, d.sandbox, target.root.Kind, target.path); err != nil {
+			return nil, davError(err)
+		}
+		davCacheFrom(ctx).clear()
+		info = davFileInfo{name: path.Base(target.rel)}
+	}
+
+	file := &davFile{fs: d, ctx: ctx, name: name, target: target, info: info, writable: writable, created: !exists}
+	// 既存のドキュメントを空にして開いた場合は書き込みが無くても保存する
+	if exists && writable && flag&os.O_TRUNC != 0 {
+		file.dirty = true
+	}
+	if exists && flag&os.O_TRUNC == 0 {
+		content, err := d.readFile(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		file.content = []byte(content)
+	}
+	return file, nil
+}
+
+// Mkdir はプロバイダーにディレクトリを作成する操作が無いため許可しない
+func (d *davFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	return os.ErrPermission
+}
+
+func (d *davFS) RemoveAll(ctx context.Context, name string) error {
+	target, err := d.resolve(ctx, name)
+	if err != nil {
+		return err
+	}
+	info, err := d.Stat(ctx, name)
+	if err != nil {
+		return err
+	}
+	// ディレクトリごとの削除は許可しない
+	if target.isRoot() || info.IsDir() {
+		return os.ErrPermission
+	}
+	defer davCacheFrom(ctx).clear()
+	return davError(deleteDocument(ctx, d.registry, d.sandbox, target.root.Kind, target.path))
+}
+
+// Rename は同じ種類の取得元の間でのみ、内容の書き込みと元のドキュメントの削除で実現する
+// エディタが一時ファイルに保存してから名前を変更する場合に使われる
+func (d *davFS) Rename(ctx context.Context, oldName string, newName string) error {
+	from, err := d.resolve(ctx, oldName)
+	if err != nil {
+		return err
+	}
+	to, err := d.resolve(ctx, newName)
+	if err != nil {
+		return err
+	}
+	if from.isRoot() || to.isRoot() || from.root.Kind != to.root.Kind || !isDocumentPath(to.path) {
+		return os.ErrPermission
+	}
+	info, err := d.Stat(ctx, oldName)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return os.ErrPermission
+	}
+
+	content, err := d.readFile(ctx, from)
+	if err != nil {
+		return err
+	}
+	defer davCacheFrom(ctx).clear()
+	if _, err := d.Stat(ctx, newName); errors.Is(err, os.ErrNotExist) {
+		if err := createDocument(ctx, d.registry, d.sandbox, to.root.Kind, to.path); err != nil {
+			return davError(err)
+		}
+	}
+	if _, err := updateDocumentContent(ctx, d.registry, d.sandbox, to.root.Kind, to.path, content, ""); err != nil {
+		return davError(err)
+	}
+	return davError(deleteDocument(ctx, d.registry, d.sandbox, from.root.Kind, from.path))
+}
+
+// davError はハンドラーのエラーをwebdavパッケージが扱えるエラーに変換する
+func davError(err error) error {
+	var statusErr huma.StatusError
+	if !errors.As(err, &statusErr) {
+		return err
+	}
+	switch statusErr.GetStatus() {
+	case http.StatusForbidden, http.StatusNotImplemented:
+		return os.ErrPermission
+	case http.StatusNotFound:
+		return os.ErrNotExist
+	case http.StatusBadRequest:
+		var unknownKind *UnknownKindError
+		if errors.As(err, &unknownKind) {
+			return os.ErrNotExist
+		}
+	}
+	return err
+}
+
+type davFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+	etag    string
+}
+
+var _ webdav.ETager = davFileInfo{}
+
+func (i davFileInfo) Name() string { return i.name }
+func (i davFileInfo) Size() int64  { return i.size }
+func (i davFileInfo) Mode() fs.FileMode {
+	if i.isDir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+
+// 更新日時を返さないプロバイダーではゼロ値となる
+func (i davFileInfo) ModTime() time.Time { return i.modTime }
+func (i davFileInfo) IsDir() bool        { return i.isDir }
+func (i davFileInfo) Sys() any           { return nil }
+
+// ETag は更新日時とサイズ、更新日時が分からない場合は内容のハッシュから作る
+// webdavパッケージの既定は更新日時とサイズのため、更新日時が無いと同じサイズの変更を区別できない
+func (i davFileInfo) ETag(ctx context.Context) (string, error) {
+	if i.etag == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return i.etag, nil
+}
+
+// modTimeETag はwebdavパッケージの既定と同じ形式のETagを返す
+func modTimeETag(modTime time.Time, size int64) string {
+	return fmt.Sprintf(`"%x%x"`, modTime.UnixNano(), size)
+}
+
+func contentETag(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return fmt.Sprintf(`"%x"`, sum[:16])
+}
+
+// davWrittenFileInfo は書き込んだファイルの情報
+// webdavパッケージは保存前にStatを呼び、保存後にETagを求めるため、ETagは保存後の取得元の情報から作る
+type davWrittenFileInfo struct {
+	davFileInfo
+	fs   *davFS
+	name string
+}
+
+func (i davWrittenFileInfo) ETag(ctx context.Context) (string, error) {
+	info, err := i.fs.Stat(ctx, i.name)
+	if err != nil {
+		return "", err
+	}
+	if etager, ok := info.(webdav.ETager); ok {
+		return etager.ETag(ctx)
+	}
+	return "", webdav.ErrNotImplemented
+}
+
+// davFile はドキュメントの内容をメモリ上に保持し、閉じるときに書き込む
+type davFile struct {
+	fs       *davFS
+	ctx      context.Context
+	name     string
+	target   davTarget
+	info     fs.FileInfo
+	writable bool
+	created  bool
+
+	content []byte
+	offset  int64
+	dirty   bool
+	entries []fs.FileInfo
+	listed  bool
+}
+
+var _ webdav.File = (*davFile)(nil)
+
+func (f *davFile) Read(p []byte) (int, error) {
+	if f.offset >= int64(len(f.content)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.content[f.offset:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *davFile) Write(p []byte) (int, error) {
+	if !f.writable {
+		return 0, os.ErrPermission
+	}
+	end := f.offset + int64(len(p))
+	if end > int64(len(f.content)) {
+		f.content = append(f.content, make([]byte, end-int64(len(f.content)))...)
+	}
+	copy(f.content[f.offset:], p)
+	f.offset = end
+	f.dirty = true
+	return len(p), nil
+}
+
+func (f *davFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.content))
+	default:
+		return 0, os.ErrInvalid
+	}
+	if offset < 0 {
+		return 0, os.ErrInvalid
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *davFile) Readdir(count int) ([]fs.FileInfo, error) {
+	if !f.info.IsDir() {
+		return nil, os.ErrInvalid
+	}
+	if !f.listed {
+		entries, err := f.fs.readDir(f.ctx, f.target)
+		if err != nil {
+			return nil, err
+		}
+		f.entries = entries
+		f.listed = true
+	}
+
+	if count <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	n := min(count, len(f.entries))
+	entries := f.entries[:n]
+	f.entries = f.entries[n:]
+	return entries, nil
+}
+
+func (f *davFile) Stat() (fs.FileInfo, error) {
+	if f.info.IsDir() || !f.dirty && !f.created {
+		return f.info, nil
+	}
+	info := davFileInfo{name: f.info.Name(), size: int64(len(f.content))}
+	return davWrittenFileInfo{davFileInfo: info, fs: f.fs, name: f.name}, nil
+}
+
+// Close は書き込まれた内容をPUT /document/contentと同じ処理で保存する
+func (f *davFile) Close() error {
+	if !f.dirty {
+		return nil
+	}
+	defer davCacheFrom(f.ctx).clear()
+	_, err := updateDocumentContent(f.ctx, f.fs.registry, f.fs.sandbox, f.target.root.Kind, f.target.path, string(f.content), "")
+	return davError(err)
+}
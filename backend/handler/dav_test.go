@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"backend/config"
+	"backend/config/configtest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestDAV(t *testing.T, documents *memoryDocuments) http.Handler {
+	t.Helper()
+	appConfig := &config.AppConfig{AppMode: config.CLI}
+	appConfig.LocalFile.Directories = []string{"/notes"}
+	provider := configtest.NewProvider(appConfig)
+	return newDAVHandler(provider, newTestRegistry(t, documents), newPathSandbox(config.CLI, provider))
+}
+
+func davRequest(t *testing.T, h http.Handler, method string, target string, body string, header map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestDAVCreatesOnlyMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		header  map[string]string
+		created string
+		want    int
+	}{
+		{
+			name:    "put markdown",
+			method:  http.MethodPut,
+			target:  "/dav/notes/new.md",
+			created: "/notes/new.md",
+			want:    http.StatusCreated,
+		},
+		{
+			name:    "put script",
+			method:  http.MethodPut,
+			target:  "/dav/notes/run.sh",
+			created: "/notes/run.sh",
+			want:    http.StatusNotFound,
+		},
+		{
+			name:    "put dotfile",
+			method:  http.MethodPut,
+			target:  "/dav/notes/.env",
+			created: "/notes/.env",
+			want:    http.StatusNotFound,
+		},
+		{
+			name:    "move to markdown",
+			method:  "MOVE",
+			target:  "/dav/notes/a.md",
+			header:  map[string]string{"Destination": "/dav/notes/b.md"},
+			created: "/notes/b.md",
+			want:    http.StatusCreated,
+		},
+		{
+			name:    "move to script",
+			method:  "MOVE",
+			target:  "/dav/notes/a.md",
+			header:  map[string]string{"Destination": "/dav/notes/a.sh"},
+			created: "/notes/a.sh",
+			want:    http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documents := newMemoryDocuments(map[string]string{"/notes/a.md": "# A\n"})
+			h := newTestDAV(t, documents)
+			w := davRequest(t, h, tt.method, tt.target, "content", tt.header)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if created := documents.exists(tt.created); created != (tt.want < 300) {
+				t.Errorf("%s exists = %v", tt.created, created)
+			}
+			if tt.method == "MOVE" && documents.exists("/notes/a.md") != (tt.want >= 300) {
+				t.Errorf("source was removed = %v after status %d", !documents.exists("/notes/a.md"), w.Code)
+			}
+		})
+	}
+}
+
+func TestDAVReadWrite(t *testing.T) {
+	documents := newMemoryDocuments(map[string]string{"/notes/a.md": "# A\n", "/notes/sub/b.md": "# B\n"})
+	h := newTestDAV(t, documents)
+
+	w := davRequest(t, h, "PROPFIND", "/dav/notes/", "", map[string]string{"Depth": "1"})
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("PROPFIND status = %d", w.Code)
+	}
+	for _, want := range []string{"/dav/notes/a.md", "/dav/notes/sub/"} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("PROPFIND does not list %s: %s", want, w.Body)
+		}
+	}
+
+	if w := davRequest(t, h, http.MethodGet, "/dav/notes/sub/b.md", "", nil); w.Code != http.StatusOK || w.Body.String() != "# B\n" {
+		t.Errorf("GET = %d %q", w.Code, w.Body)
+	}
+
+	if w := davRequest(t, h, http.MethodPut, "/dav/notes/a.md", "# Changed\n", nil); w.Code != http.StatusCreated {
+		t.Errorf("PUT status = %d", w.Code)
+	}
+	if content, _ := documents.GetDocumentContent(t.Context(), "/notes/a.md"); content != "# Changed\n" {
+		t.Errorf("content after PUT = %q", content)
+	}
+
+	if w := davRequest(t, h, "MKCOL", "/dav/notes/new/", "", nil); w.Code < 400 {
+		t.Errorf("MKCOL status = %d", w.Code)
+	}
+	if w := davRequest(t, h, http.MethodDelete, "/dav/notes/sub/", "", nil); w.Code < 400 {
+		t.Errorf("DELETE of a directory status = %d", w.Code)
+	}
+	if w := davRequest(t, h, http.MethodDelete, "/dav/notes/a.md", "", nil); w.Code != http.StatusNoContent || documents.exists("/notes/a.md") {
+		t.Errorf("DELETE status = %d", w.Code)
+	}
+}
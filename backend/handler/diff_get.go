@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"backend/diff"
+	"context"
+	"errors"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// DocumentRevisionProvider は過去のリビジョン（gitのコミットなど）のドキュメントを読めるプロバイダー
+// リビジョンが存在しない場合はErrRevisionNotFound、使えない場合はerrors.ErrUnsupportedを返す
+type DocumentRevisionProvider interface {
+	GetDocumentContentAt(ctx context.Context, path string, revision string) (string, error)
+}
+
+// ErrRevisionNotFound はリビジョンが存在しないか、その時点にドキュメントが存在しないことを表す
+var ErrRevisionNotFound = errors.New("revision not found")
+
+type GetDiffInput struct {
+	Path      string `query:"path" example:"/home/user/document.md" doc:"Absolute path to the document to compare from"`
+	Kind      string `query:"kind" example:"local" doc:"Kind of document source (e.g., 'local', 'github')"`
+	Rev       string `query:"rev" example:"HEAD~1" doc:"Git revision of the document to compare from (saved content when empty)"`
+	OtherPath string `query:"other_path" example:"/home/user/other.md" doc:"Document to compare to (the same document when empty)"`
+	OtherKind string `query:"other_kind" example:"s3" doc:"Kind of the document to compare to (same as kind when empty)"`
+	OtherRev  string `query:"other_rev" example:"HEAD" doc:"Git revision of the document to compare to (saved content when empty)"`
+	Context   int    `query:"context" default:"3" minimum:"0" maximum:"1000" doc:"Number of unchanged lines around each change"`
+}
+
+type DiffOutput struct {
+	Body struct {
+		Unified string      `json:"unified" doc:"Diff in unified format (empty when there is no difference)"`
+		Hunks   []diff.Hunk `json:"hunks" doc:"Changed lines with surrounding lines, including word-level changes"`
+		Added   int         `json:"added" doc:"Number of added lines"`
+		Deleted int         `json:"deleted" doc:"Number of deleted lines"`
+	}
+}
+
+// newDiffHandler は2つのドキュメントを比較する
+// 同じドキュメントの2つのリビジョン、または異なるプロバイダーのドキュメント同士を比較できる
+func newDiffHandler(api huma.API, registry *ProviderRegistry, sandbox *pathSandbox) {
+	huma.Get(api, "/diff", func(ctx context.Context, input *GetDiffInput) (*DiffOutput, error) {
+		if input.OtherPath == "" && input.Rev == "" && input.OtherRev == "" {
+			return nil, huma.Error400BadRequest("Nothing to compare; specify rev, other_rev or other_path, or POST a draft")
+		}
+
+		oldContent, err := readRevision(ctx, registry, sandbox, input.Kind, input.Path, input.Rev)
+		if err != nil {
+			return nil, err
+		}
+		otherKind, otherPath := input.Kind, input.Path
+		if input.OtherPath != "" {
+			otherPath = input.OtherPath
+			if input.OtherKind != "" {
+				otherKind = input.OtherKind
+			}
+		}
+		newContent, err := readRevision(ctx, registry, sandbox, otherKind, otherPath, input.OtherRev)
+		if err != nil {
+			return nil, err
+		}
+
+		return newDiffOutput(
+			diffLabel(input.Kind, input.Path, input.Rev), oldContent,
+			diffLabel(otherKind, otherPath, input.OtherRev), newContent,
+			input.Context,
+		), nil
+	})
+}
+
+// readRevision はrevisionの時点のドキュメントを読む（revisionが空の場合は保存されている内容）
+func readRevision(ctx context.Context, registry *ProviderRegistry, sandbox *pathSandbox, kindValue string, path string, revision string) (string, error) {
+	if revision == "" {
+		provider, kind, err := lookupProvider[DocumentContentProvider](ctx, registry, kindValue, CapabilityRead)
+		if err != nil {
+			return "", err
+		}
+		if err := sandbox.checkRead(ctx, kind, path); err != nil {
+			return "", err
+		}
+		content, err := provider.GetDocumentContent(ctx, path)
+		if err != nil {
+			logProviderError(ctx, kind, "GetDocumentContent", err)
+			return "", huma.Error400BadRequest("Failed to read document content", err)
+		}
+		return content, nil
+	}
+
+	provider, kind, err := lookupProvider[DocumentRevisionProvider](ctx, registry, kindValue, CapabilityRead)
+	if err != nil {
+		return "", err
+	}
+	if err := sandbox.checkRead(ctx, kind, path); err != nil {
+		return "", err
+	}
+	content, err := provider.GetDocumentContentAt(ctx, path, revision)
+	switch {
+	case errors.Is(err, ErrRevisionNotFound):
+		return "", huma.Error404NotFound("Revision not found", err)
+	case errors.Is(err, errors.ErrUnsupported):
+		return "", huma.Error501NotImplemented("Revisions are not available for this document", err)
+	case err != nil:
+		logProviderError(ctx, kind, "GetDocumentContentAt", err)
+		return "", huma.Error400BadRequest("Failed to read document revision", err)
+	}
+	return content, nil
+}
+
+// diffLabel はunified形式の---と+++の行に表示する名前を返す
+func diffLabel(kind string, path string, revision string) string {
+	label := path
+	if kind != "" {
+		label = kind + ":" + label
+	}
+	if revision != "" {
+		label += "@" + revision
+	}
+	return label
+}
+
+func newDiffOutput(oldName string, oldContent string, newName string, newContent string, context int) *DiffOutput {
+	hunks := diff.Lines(oldContent, newContent, context)
+	resp := &DiffOutput{}
+	resp.Body.Unified = diff.Unified(oldName, newName, hunks)
+	resp.Body.Hunks = hunks
+	if resp.Body.Hunks == nil {
+		resp.Body.Hunks = []diff.Hunk{}
+	}
+	resp.Body.Added, resp.Body.Deleted = diff.Count(hunks)
+	return resp
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type PostDiffInput struct {
+	Path    string `query:"path" example:"/home/user/document.md" doc:"Absolute path to the document"`
+	Kind    string `query:"kind" example:"local" doc:"Kind of document source (e.g., 'local', 'github')"`
+	Rev     string `query:"rev" example:"HEAD" doc:"Git revision of the document to compare from (saved content when empty)"`
+	Context int    `query:"context" default:"3" minimum:"0" maximum:"1000" doc:"Number of unchanged lines around each change"`
+	Body    struct {
+		Draft string `json:"draft" doc:"Unsaved content to compare to the document"`
+	}
+}
+
+// newDiffDraftHandler は保存されているドキュメントと編集中の内容を比較する
+// 保存前に変更内容を確認するために使う
+func newDiffDraftHandler(api huma.API, registry *ProviderRegistry, sandbox *pathSandbox) {
+	huma.Post(api, "/diff", func(ctx context.Context, input *PostDiffInput) (*DiffOutput, error) {
+		content, err := readRevision(ctx, registry, sandbox, input.Kind, input.Path, input.Rev)
+		if err != nil {
+			return nil, err
+		}
+		return newDiffOutput(diffLabel(input.Kind, input.Path, input.Rev), content, "draft", input.Body.Draft, input.Context), nil
+	})
+}
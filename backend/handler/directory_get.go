@@ -1,20 +1,22 @@
 package handler
 
 import (
-	"backend/domain"
 	"context"
-	"fmt"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 )
 
+// FileInfo はディレクトリの要素
+// ModTimeを返すプロバイダーはSizeも返す
 type FileInfo struct {
-	Name  string `json:"name" example:"file.txt" doc:"File or directory name"`
-	IsDir bool   `json:"is_dir" example:"false" doc:"Whether this is a directory"`
+	Name    string    `json:"name" example:"file.txt" doc:"File or directory name"`
+	IsDir   bool      `json:"is_dir" example:"false" doc:"Whether this is a directory"`
+	Size    int64     `json:"size,omitempty" doc:"Size of the file in bytes (not set for directories or when unknown)"`
+	ModTime time.Time `json:"mod_time,omitzero" doc:"Last modification time (not set when unknown)"`
 }
 
 type DirectoryProvider interface {
-	Match(kind domain.RepoKind) bool
 	GetDirectory(ctx context.Context, path string) ([]FileInfo, error)
 }
 
@@ -30,26 +32,19 @@ type GetDirectoryOutput struct {
 	}
 }
 
-func newDirectoryHandler(api huma.API, providers []DirectoryProvider) {
+func newDirectoryHandler(api huma.API, registry *ProviderRegistry, sandbox *pathSandbox) {
 	huma.Get(api, "/directory", func(ctx context.Context, input *GetDirectoryInput) (*GetDirectoryOutput, error) {
-		kind, err := domain.ParseRepoKind(input.Kind)
+		provider, kind, err := lookupProvider[DirectoryProvider](ctx, registry, input.Kind, CapabilityBrowse)
 		if err != nil {
 			return nil, err
 		}
-
-		var provider DirectoryProvider
-		for _, p := range providers {
-			if p.Match(kind) {
-				provider = p
-				break
-			}
-		}
-		if provider == nil {
-			return nil, fmt.Errorf("no provider found for kind: %s", input.Kind)
+		if err := sandbox.checkRead(ctx, kind, input.Path); err != nil {
+			return nil, err
 		}
 
 		items, err := provider.GetDirectory(ctx, input.Path)
 		if err != nil {
+			logProviderError(ctx, kind, "GetDirectory", err)
 			return nil, huma.Error400BadRequest("Failed to read directory", err)
 		}
 
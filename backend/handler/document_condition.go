@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// MatchesFile はdirName直下のfileNameが条件を満たすかを返す
+// dirNameはファイルを含むディレクトリの名前（パスではない）
+func (c DocumentCondition) MatchesFile(dirName, fileName string) bool {
+	// 除外条件をチェック
+	// 拡張子の除外チェック
+	for _, ext := range c.Excludes.Exts {
+		if ext != "" && strings.HasSuffix(fileName, "."+ext) {
+			return false
+		}
+	}
+	// ディレクトリ名の除外チェック
+	for _, excludeDirName := range c.Excludes.DirNames {
+		if excludeDirName != "" && matchPattern(excludeDirName, dirName) {
+			return false
+		}
+	}
+
+	// 含む条件をチェック
+	// 拡張子か拡張子リストが空の場合は全て対象
+	extMatches := len(c.Includes.Exts) == 0
+	if !extMatches {
+		for _, ext := range c.Includes.Exts {
+			if ext != "" && strings.HasSuffix(fileName, "."+ext) {
+				extMatches = true
+				break
+			}
+		}
+	}
+
+	// ディレクトリ名の条件チェック（空の場合は全て対象、*の場合も全て対象）
+	dirMatches := len(c.Includes.DirNames) == 0
+	if !dirMatches {
+		for _, includeDirName := range c.Includes.DirNames {
+			if includeDirName == "*" || matchPattern(includeDirName, dirName) {
+				dirMatches = true
+				break
+			}
+		}
+	}
+
+	return extMatches && dirMatches
+}
+
+// ExcludesDir は名前がnameのディレクトリを走査対象から外すかを返す
+func (c DocumentCondition) ExcludesDir(name string) bool {
+	for _, dirName := range c.Excludes.DirNames {
+		if dirName != "" && matchPattern(dirName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// シンプルなパターンマッチング（*をワイルドカードとして使用）
+func matchPattern(pattern, str string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if pattern == str {
+		return true
+	}
+	// より複雑なパターンマッチングが必要な場合は filepath.Match を使用
+	matched, _ := filepath.Match(pattern, str)
+	return matched
+}
@@ -1,56 +1,63 @@
 package handler
 
 import (
-	"backend/domain"
 	"context"
-	"fmt"
 
 	"github.com/danielgtaylor/huma/v2"
 )
 
 type DocumentContentProvider interface {
-	Match(kind domain.RepoKind) bool
 	GetDocumentContent(ctx context.Context, path string) (string, error)
 }
 
+// VersionedDocumentContentProvider はドキュメントのバージョン（ETagなど）も返せるプロバイダー
+// バージョンはPUT /document/contentのIf-Matchで楽観的排他制御に使用する
+type VersionedDocumentContentProvider interface {
+	GetDocumentContentVersion(ctx context.Context, path string) (content string, version string, err error)
+}
+
 type GetDocumentContentInput struct {
 	Path string `query:"path" example:"/home/user/document.md" doc:"Absolute path to the document"`
 	Kind string `query:"kind" example:"local" doc:"Kind of document source (e.g., 'local', 'github')"`
 }
 
 type GetDocumentContentOutput struct {
+	ETag string `header:"ETag" doc:"Version of the document, only for providers supporting optimistic concurrency"`
 	Body struct {
 		Path    string `json:"path" example:"/home/user/document.md" doc:"Document path"`
 		Content string `json:"content" doc:"Document content"`
+		Version string `json:"version,omitempty" doc:"Version of the document to send as If-Match when updating"`
 	}
 }
 
-func NewDocumentContentHandler(api huma.API, providers []DocumentContentProvider) {
+func NewDocumentContentHandler(api huma.API, registry *ProviderRegistry, sandbox *pathSandbox) {
 	huma.Get(api, "/document/content", func(ctx context.Context, input *GetDocumentContentInput) (*GetDocumentContentOutput, error) {
-		kind, err := domain.ParseRepoKind(input.Kind)
+		provider, kind, err := lookupProvider[DocumentContentProvider](ctx, registry, input.Kind, CapabilityRead)
 		if err != nil {
 			return nil, err
 		}
-
-		var provider DocumentContentProvider
-		for _, p := range providers {
-			if p.Match(kind) {
-				provider = p
-				break
-			}
-		}
-		if provider == nil {
-			return nil, fmt.Errorf("no provider found for kind: %s", input.Kind)
+		if err := sandbox.checkRead(ctx, kind, input.Path); err != nil {
+			return nil, err
 		}
 
-		content, err := provider.GetDocumentContent(ctx, input.Path)
+		var content, version string
+		if versioned, ok := provider.(VersionedDocumentContentProvider); ok {
+			content, version, err = versioned.GetDocumentContentVersion(ctx, input.Path)
+		} else {
+			content, err = provider.GetDocumentContent(ctx, input.Path)
+		}
 		if err != nil {
+			logProviderError(ctx, kind, "GetDocumentContent", err)
 			return nil, huma.Error400BadRequest("Failed to read document content", err)
 		}
 
 		resp := &GetDocumentContentOutput{}
 		resp.Body.Path = input.Path
 		resp.Body.Content = content
+		if version != "" {
+			resp.ETag = quoteETag(version)
+			resp.Body.Version = version
+		}
 
 		return resp, nil
 	})
@@ -1,62 +1,107 @@
 package handler
 
 import (
-	"backend/domain"
+	"backend/markdown"
 	"context"
-	"fmt"
+	"errors"
+	"strings"
 
 	"github.com/danielgtaylor/huma/v2"
 )
 
 type DocumentContentUpdateProvider interface {
-	Match(kind domain.RepoKind) bool
 	UpdateDocumentContent(ctx context.Context, path string, content string) error
 }
 
+// ConditionalDocumentContentUpdateProvider はバージョンが一致する場合のみ更新できるプロバイダー
+// バージョンが一致しない場合はErrVersionMismatchを返す
+type ConditionalDocumentContentUpdateProvider interface {
+	UpdateDocumentContentIfMatch(ctx context.Context, path string, content string, version string) (newVersion string, err error)
+}
+
+// ErrVersionMismatch は読み込んだ後に他の誰かがドキュメントを更新したことを表す
+var ErrVersionMismatch = errors.New("document was modified after it was read")
+
 type UpdateDocumentContentInput struct {
-	Path string `query:"path" example:"/home/user/document.md" doc:"Absolute path to the document"`
-	Kind string `query:"kind" example:"local" doc:"Kind of document source (e.g., 'local', 'github')"`
-	Body struct {
+	Path    string `query:"path" example:"/home/user/document.md" doc:"Absolute path to the document"`
+	Kind    string `query:"kind" example:"local" doc:"Kind of document source (e.g., 'local', 'github')"`
+	IfMatch string `header:"If-Match" doc:"Update only if the document still has this version (ETag)"`
+	Format  bool   `query:"format" doc:"Format the Markdown before saving"`
+	Body    struct {
 		Content string `json:"content" doc:"New content for the document"`
 	}
 }
 
 type UpdateDocumentContentOutput struct {
+	ETag string `header:"ETag" doc:"New version of the document"`
 	Body struct {
 		Path    string `json:"path" example:"/home/user/document.md" doc:"Document path"`
 		Success bool   `json:"success" doc:"Whether the update was successful"`
 		Message string `json:"message" doc:"Success or error message"`
+		Content string `json:"content,omitempty" doc:"Saved content when it was formatted before saving"`
 	}
 }
 
-func NewDocumentContentUpdateHandler(api huma.API, providers []DocumentContentUpdateProvider) {
+func NewDocumentContentUpdateHandler(api huma.API, registry *ProviderRegistry, sandbox *pathSandbox) {
 	huma.Put(api, "/document/content", func(ctx context.Context, input *UpdateDocumentContentInput) (*UpdateDocumentContentOutput, error) {
-		kind, err := domain.ParseRepoKind(input.Kind)
-		if err != nil {
-			return nil, err
+		content := input.Body.Content
+		if input.Format {
+			content = markdown.Format(content)
 		}
 
-		var provider DocumentContentUpdateProvider
-		for _, p := range providers {
-			if p.Match(kind) {
-				provider = p
-				break
-			}
-		}
-		if provider == nil {
-			return nil, fmt.Errorf("no provider found for kind: %s", input.Kind)
-		}
-
-		err = provider.UpdateDocumentContent(ctx, input.Path, input.Body.Content)
+		version, err := updateDocumentContent(ctx, registry, sandbox, input.Kind, input.Path, content, unquoteETag(input.IfMatch))
 		if err != nil {
-			return nil, huma.Error400BadRequest("Failed to update document content", err)
+			return nil, err
 		}
 
 		resp := &UpdateDocumentContentOutput{}
+		if version != "" {
+			resp.ETag = quoteETag(version)
+		}
 		resp.Body.Path = input.Path
 		resp.Body.Success = true
 		resp.Body.Message = "Document updated successfully"
+		if input.Format {
+			resp.Body.Content = content
+		}
 
 		return resp, nil
 	})
 }
+
+// updateDocumentContent はドキュメントの内容を更新し、プロバイダーが返した新しいバージョンを返す
+// WebDAVからの書き込みも同じ処理を通す
+func updateDocumentContent(ctx context.Context, registry *ProviderRegistry, sandbox *pathSandbox, kindValue string, path string, content string, ifMatch string) (string, error) {
+	provider, kind, err := lookupProvider[DocumentContentUpdateProvider](ctx, registry, kindValue, CapabilityWrite)
+	if err != nil {
+		return "", err
+	}
+	if err := sandbox.checkWrite(ctx, kind, path); err != nil {
+		return "", err
+	}
+
+	// If-Matchはバージョンを扱えるプロバイダーでのみ評価する
+	var version string
+	conditional, ok := provider.(ConditionalDocumentContentUpdateProvider)
+	if ifMatch != "" && ok {
+		version, err = conditional.UpdateDocumentContentIfMatch(ctx, path, content, ifMatch)
+	} else {
+		err = provider.UpdateDocumentContent(ctx, path, content)
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		return "", huma.Error412PreconditionFailed("Document was modified by someone else; reload it and try again", err)
+	}
+	if err != nil {
+		logProviderError(ctx, kind, "UpdateDocumentContent", err)
+		return "", huma.Error400BadRequest("Failed to update document content", err)
+	}
+	return version, nil
+}
+
+func quoteETag(version string) string {
+	return `"` + version + `"`
+}
+
+func unquoteETag(etag string) string {
+	return strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+}
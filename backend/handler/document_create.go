@@ -1,21 +1,28 @@
 package handler
 
 import (
-	"backend/domain"
 	"context"
-	"fmt"
-	"os"
-	"path/filepath"
+	"errors"
 	"strings"
 
 	"github.com/danielgtaylor/huma/v2"
 )
 
 type DocumentCreateProvider interface {
-	Match(kind domain.RepoKind) bool
 	CreateDocument(ctx context.Context, path string) error
 }
 
+// ErrDocumentExists は作成しようとしたドキュメントが既に存在することを表す
+var ErrDocumentExists = errors.New("document already exists")
+
+// documentExt は作成できるドキュメントの拡張子
+const documentExt = ".md"
+
+// isDocumentPath はpathが作成できるドキュメントの名前かを返す
+func isDocumentPath(path string) bool {
+	return strings.HasSuffix(path, documentExt)
+}
+
 type CreateDocumentInput struct {
 	Body struct {
 		Path string `json:"path" example:"/home/user/new-document.md" doc:"Absolute path for the new document (must end with .md)"`
@@ -30,45 +37,12 @@ type CreateDocumentOutput struct {
 	}
 }
 
-func NewDocumentCreateHandler(api huma.API, providers []DocumentCreateProvider) {
+func NewDocumentCreateHandler(api huma.API, registry *ProviderRegistry, sandbox *pathSandbox) {
 	huma.Post(api, "/document", func(ctx context.Context, input *CreateDocumentInput) (*CreateDocumentOutput, error) {
-		// Validate that the file extension is .md
-		if !strings.HasSuffix(input.Body.Path, ".md") {
-			return nil, huma.Error400BadRequest("File must have .md extension", nil)
-		}
-
-		kind, err := domain.ParseRepoKind(input.Body.Kind)
-		if err != nil {
+		if err := createDocument(ctx, registry, sandbox, input.Body.Kind, input.Body.Path); err != nil {
 			return nil, err
 		}
 
-		var provider DocumentCreateProvider
-		for _, p := range providers {
-			if p.Match(kind) {
-				provider = p
-				break
-			}
-		}
-		if provider == nil {
-			return nil, fmt.Errorf("no provider found for kind: %s", input.Body.Kind)
-		}
-
-		// Check if file already exists
-		if _, err := os.Stat(input.Body.Path); err == nil {
-			return nil, huma.Error400BadRequest("File already exists", nil)
-		}
-
-		// Create directory if it doesn't exist
-		dir := filepath.Dir(input.Body.Path)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return nil, huma.Error500InternalServerError("Failed to create directory", err)
-		}
-
-		err = provider.CreateDocument(ctx, input.Body.Path)
-		if err != nil {
-			return nil, huma.Error500InternalServerError("Failed to create document", err)
-		}
-
 		resp := &CreateDocumentOutput{}
 		resp.Body.Path = input.Body.Path
 		resp.Body.Success = true
@@ -76,3 +50,29 @@ func NewDocumentCreateHandler(api huma.API, providers []DocumentCreateProvider)
 		return resp, nil
 	})
 }
+
+// createDocument は空のドキュメントを作成する
+// WebDAVからの作成や名前の変更も同じ処理を通し、.md以外のファイルは作らせない
+func createDocument(ctx context.Context, registry *ProviderRegistry, sandbox *pathSandbox, kindValue string, path string) error {
+	if !isDocumentPath(path) {
+		return huma.Error400BadRequest("File must have .md extension", nil)
+	}
+	provider, kind, err := lookupProvider[DocumentCreateProvider](ctx, registry, kindValue, CapabilityCreate)
+	if err != nil {
+		return err
+	}
+	if err := sandbox.checkWrite(ctx, kind, path); err != nil {
+		return err
+	}
+
+	// 存在確認やディレクトリの作成はプロバイダーに任せる
+	err = provider.CreateDocument(ctx, path)
+	if errors.Is(err, ErrDocumentExists) {
+		return huma.Error400BadRequest("File already exists", nil)
+	}
+	if err != nil {
+		logProviderError(ctx, kind, "CreateDocument", err)
+		return huma.Error500InternalServerError("Failed to create document", err)
+	}
+	return nil
+}
@@ -1,16 +1,14 @@
 package handler
 
 import (
-	"backend/domain"
 	"context"
-	"fmt"
-	"os"
+	"errors"
+	"io/fs"
 
 	"github.com/danielgtaylor/huma/v2"
 )
 
 type DocumentDeleteProvider interface {
-	Match(kind domain.RepoKind) bool
 	DeleteDocument(ctx context.Context, path string) error
 }
 
@@ -28,34 +26,12 @@ type DeleteDocumentOutput struct {
 	}
 }
 
-func NewDocumentDeleteHandler(api huma.API, providers []DocumentDeleteProvider) {
+func NewDocumentDeleteHandler(api huma.API, registry *ProviderRegistry, sandbox *pathSandbox) {
 	huma.Delete(api, "/document", func(ctx context.Context, input *DeleteDocumentInput) (*DeleteDocumentOutput, error) {
-		kind, err := domain.ParseRepoKind(input.Body.Kind)
-		if err != nil {
+		if err := deleteDocument(ctx, registry, sandbox, input.Body.Kind, input.Body.Path); err != nil {
 			return nil, err
 		}
 
-		var provider DocumentDeleteProvider
-		for _, p := range providers {
-			if p.Match(kind) {
-				provider = p
-				break
-			}
-		}
-		if provider == nil {
-			return nil, fmt.Errorf("no provider found for kind: %s", input.Body.Kind)
-		}
-
-		// Check if file exists
-		if _, err := os.Stat(input.Body.Path); os.IsNotExist(err) {
-			return nil, huma.Error404NotFound("File does not exist", nil)
-		}
-
-		err = provider.DeleteDocument(ctx, input.Body.Path)
-		if err != nil {
-			return nil, huma.Error500InternalServerError("Failed to delete document", err)
-		}
-
 		resp := &DeleteDocumentOutput{}
 		resp.Body.Path = input.Body.Path
 		resp.Body.Success = true
@@ -63,3 +39,25 @@ func NewDocumentDeleteHandler(api huma.API, providers []DocumentDeleteProvider)
 		return resp, nil
 	})
 }
+
+// deleteDocument はドキュメントを削除する
+// WebDAVからの削除も同じ処理を通す
+func deleteDocument(ctx context.Context, registry *ProviderRegistry, sandbox *pathSandbox, kindValue string, path string) error {
+	provider, kind, err := lookupProvider[DocumentDeleteProvider](ctx, registry, kindValue, CapabilityDelete)
+	if err != nil {
+		return err
+	}
+	if err := sandbox.checkWrite(ctx, kind, path); err != nil {
+		return err
+	}
+
+	err = provider.DeleteDocument(ctx, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return huma.Error404NotFound("File does not exist", nil)
+	}
+	if err != nil {
+		logProviderError(ctx, kind, "DeleteDocument", err)
+		return huma.Error500InternalServerError("Failed to delete document", err)
+	}
+	return nil
+}
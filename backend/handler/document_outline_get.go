@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"backend/markdown"
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type GetDocumentOutlineInput struct {
+	Path string `query:"path" example:"/home/user/document.md" doc:"Absolute path to the document"`
+	Kind string `query:"kind" example:"local" doc:"Kind of document source (e.g., 'local', 'github')"`
+}
+
+type GetDocumentOutlineOutput struct {
+	Body struct {
+		Path     string             `json:"path" example:"/home/user/document.md" doc:"Document path"`
+		Headings []markdown.Heading `json:"headings" doc:"Top level headings of the document with nested subheadings"`
+	}
+}
+
+// newDocumentOutlineHandler はドキュメントの見出しを階層構造で返す
+// 見出しのslugはGitHubと同じ規則で生成するため、そのままアンカーとして使える
+func newDocumentOutlineHandler(api huma.API, registry *ProviderRegistry, sandbox *pathSandbox) {
+	huma.Get(api, "/document/outline", func(ctx context.Context, input *GetDocumentOutlineInput) (*GetDocumentOutlineOutput, error) {
+		provider, kind, err := lookupProvider[DocumentContentProvider](ctx, registry, input.Kind, CapabilityRead)
+		if err != nil {
+			return nil, err
+		}
+		if err := sandbox.checkRead(ctx, kind, input.Path); err != nil {
+			return nil, err
+		}
+
+		content, err := provider.GetDocumentContent(ctx, input.Path)
+		if err != nil {
+			logProviderError(ctx, kind, "GetDocumentContent", err)
+			return nil, huma.Error400BadRequest("Failed to read document content", err)
+		}
+
+		resp := &GetDocumentOutlineOutput{}
+		resp.Body.Path = input.Path
+		resp.Body.Headings = markdown.Outline(content)
+		return resp, nil
+	})
+}
@@ -1,9 +1,9 @@
 package handler
 
 import (
+	"backend/config"
 	"backend/domain"
 	"context"
-	"fmt"
 
 	"github.com/danielgtaylor/huma/v2"
 )
@@ -13,7 +13,6 @@ type Documents struct {
 }
 
 type DocumentsProvider interface {
-	Match(kind domain.RepoKind) bool
 	GetDocuments(ctx context.Context, path string, condition DocumentCondition) ([]domain.Document, error) // path配下に存在するドキュメントを取得する
 }
 
@@ -27,6 +26,19 @@ type DocumentCondition struct {
 	Excludes Condition
 }
 
+// DefaultDocumentCondition は /documents で使用するドキュメントの探索条件
+func DefaultDocumentCondition() DocumentCondition {
+	return DocumentCondition{
+		Includes: Condition{
+			Exts:     []string{"md"},
+			DirNames: []string{"*"},
+		},
+		Excludes: Condition{
+			DirNames: []string{".git", "node_modules", ".Trash"},
+		},
+	}
+}
+
 type GetDocumentsInput struct {
 	Path string `query:"path" example:"/home/user" doc:"Absolute path to directory"`
 	Kind string `query:"kind" example:"local" doc:"Kind of document source (e.g., 'local', 'github')"`
@@ -38,36 +50,24 @@ type GetDocumentsOutput struct {
 	}
 }
 
-func newDocumentsHandler(api huma.API, providers []DocumentsProvider) {
+func newDocumentsHandler(api huma.API, appConfigProvider config.AppConfigProvider, registry *ProviderRegistry, sandbox *pathSandbox) {
 	huma.Get(api, "/documents", func(ctx context.Context, input *GetDocumentsInput) (*GetDocumentsOutput, error) {
-		kind, err := domain.ParseRepoKind(input.Kind)
+		provider, kind, err := lookupProvider[DocumentsProvider](ctx, registry, input.Kind, CapabilityList)
 		if err != nil {
 			return nil, err
 		}
-
-		var provider DocumentsProvider
-		for _, p := range providers {
-			if p.Match(kind) {
-				provider = p
-				break
-			}
-		}
-		if provider == nil {
-			return nil, fmt.Errorf("no provider found for kind: %s", input.Kind)
+		if err := sandbox.checkRead(ctx, kind, input.Path); err != nil {
+			return nil, err
 		}
 
-		condition := DocumentCondition{
-			Includes: Condition{
-				Exts:     []string{"md"},
-				DirNames: []string{"*"},
-			},
-			Excludes: Condition{
-				DirNames: []string{".git", "node_modules", ".Trash"},
-			},
+		appConfig, err := appConfigProvider.Load(ctx)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to load configuration", err)
 		}
 
-		doc, err := provider.GetDocuments(ctx, input.Path, condition)
+		doc, err := provider.GetDocuments(ctx, input.Path, DocumentConditionFor(appConfig))
 		if err != nil {
+			logProviderError(ctx, kind, "GetDocuments", err)
 			return nil, err
 		}
 		return &GetDocumentsOutput{Body: struct {
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"backend/markdown"
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type FormatInput struct {
+	Body struct {
+		Content string `json:"content" doc:"Markdown to format"`
+	}
+}
+
+type FormatOutput struct {
+	Body struct {
+		Content string `json:"content" doc:"Formatted Markdown"`
+		Changed bool   `json:"changed" doc:"Whether formatting changed the content"`
+	}
+}
+
+// newFormatHandler はMarkdownの書式を整える
+// コードフェンス、HTMLブロック、フロントマターの中は変更しない
+func newFormatHandler(api huma.API) {
+	huma.Post(api, "/format", func(ctx context.Context, input *FormatInput) (*FormatOutput, error) {
+		resp := &FormatOutput{}
+		resp.Body.Content = markdown.Format(input.Body.Content)
+		resp.Body.Changed = resp.Body.Content != input.Body.Content
+		return resp, nil
+	})
+}
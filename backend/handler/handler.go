@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"backend/buildinfo"
 	"backend/config"
+	"backend/metrics"
 	"net/http"
 
 	"github.com/danielgtaylor/huma/v2"
@@ -11,13 +13,48 @@ import (
 	_ "github.com/fxamacker/cbor/v2"
 )
 
+// APIのパスの接頭辞（フロントエンドは同じオリジンの /api を呼び出す）
+const APIPrefix = "/api"
+
+// PublicOperationKey はOperationのMetadataで認証が不要なことを表すキー
+const PublicOperationKey = "public"
+
+// 監視用のエンドポイントは認証なしで呼び出せるようにする
+var publicOperation = map[string]any{PublicOperationKey: true}
+
+// IsPublicOperation は認証なしで呼び出せるOperationかを返す
+func IsPublicOperation(op *huma.Operation) bool {
+	if op == nil {
+		return false
+	}
+	public, _ := op.Metadata[PublicOperationKey].(bool)
+	return public
+}
+
 type Middleware interface {
 	Use(ctx huma.Context, next func(huma.Context))
 }
 
-func newAPI() (http.Handler, huma.API) {
+// HTTPMiddleware はAPI以外（フロントエンドの配信など）にも適用するミドルウェア
+type HTTPMiddleware interface {
+	Wrap(next http.Handler) http.Handler
+}
+
+// ProtectedHTTPMiddleware はAPI以外で認証が必要なエンドポイント（WebDAVなど）に適用するミドルウェア
+type ProtectedHTTPMiddleware interface {
+	Protect(next http.Handler) http.Handler
+}
+
+func newAPI() (*chi.Mux, huma.API) {
 	router := chi.NewMux()
-	api := humachi.New(router, huma.DefaultConfig("backend", "1.0.0"))
+
+	humaConfig := huma.DefaultConfig("backend", buildinfo.Version)
+	humaConfig.Servers = []*huma.Server{{URL: APIPrefix}}
+
+	var api huma.API
+	router.Route(APIPrefix, func(r chi.Router) {
+		api = humachi.New(r, humaConfig)
+	})
 
 	return router, api
 }
@@ -34,26 +71,80 @@ func setupMiddleware(
 func NewHandler(
 	appMode config.AppMode,
 	appConfigProvider config.AppConfigProvider,
-	providers []DocumentsProvider,
-	directoryProviders []DirectoryProvider,
-	documentContentProviders []DocumentContentProvider,
-	documentContentUpdateProviders []DocumentContentUpdateProvider,
-	documentCreateProviders []DocumentCreateProvider,
-	documentDeleteProviders []DocumentDeleteProvider,
+	registry *ProviderRegistry,
+	healthCheckers []HealthChecker,
 	middlewares ...Middleware,
 ) (http.Handler, error) {
 	router, api := newAPI()
 
+	sandbox := newPathSandbox(appMode, appConfigProvider)
+
 	setupMiddleware(api, middlewares)
+	newHealthzHandler(api)
+	newReadyzHandler(api, configLoadStatus(appConfigProvider), healthCheckers, indexProgressReporters(registry))
+	newVersionHandler(api)
 	newAppConfigGetHandler(api, appConfigProvider)
 	newAppConfigUpdateHandler(api, appConfigProvider)
-	newStaticHandler(api)
-	newDocumentsHandler(api, providers)
-	newDirectoryHandler(api, directoryProviders)
-	NewDocumentContentHandler(api, documentContentProviders)
-	NewDocumentContentUpdateHandler(api, documentContentUpdateProviders)
-	NewDocumentCreateHandler(api, documentCreateProviders)
-	NewDocumentDeleteHandler(api, documentDeleteProviders)
+	newProvidersHandler(api, registry)
+	newDocumentsHandler(api, appConfigProvider, registry, sandbox)
+	newWorkspaceDocumentsHandler(api, appConfigProvider, registry)
+	newWorkspacesHandler(api, appConfigProvider)
+	newDirectoryHandler(api, registry, sandbox)
+	NewDocumentContentHandler(api, registry, sandbox)
+	newDocumentOutlineHandler(api, registry, sandbox)
+	newLintHandler(api, appConfigProvider, registry, sandbox)
+	newFormatHandler(api)
+	newDiffHandler(api, registry, sandbox)
+	newDiffDraftHandler(api, registry, sandbox)
+	NewDocumentContentUpdateHandler(api, registry, sandbox)
+	NewDocumentCreateHandler(api, registry, sandbox)
+	NewDocumentDeleteHandler(api, registry, sandbox)
+
+	spa, err := newSPAHandler()
+	if err != nil {
+		return nil, err
+	}
+	for _, mw := range middlewares {
+		if httpMiddleware, ok := mw.(HTTPMiddleware); ok {
+			spa = httpMiddleware.Wrap(spa)
+		}
+	}
+	dav := protectHandler(newDAVHandler(appConfigProvider, registry, sandbox), middlewares)
+	router.Handle(DAVPrefix, dav)
+	router.Handle(DAVPrefix+"/*", dav)
+	// メトリクスにはルートやエラーの数が含まれるため、APIと同じく認証を必要とする
+	router.Handle("/metrics", protectHandler(metrics.Handler(), middlewares))
+	router.Handle("/*", spa)
 
 	return router, nil
 }
+
+// protectHandler はAPI以外のハンドラーに認証とHTTPのミドルウェアを適用する
+func protectHandler(h http.Handler, middlewares []Middleware) http.Handler {
+	for _, mw := range middlewares {
+		if protected, ok := mw.(ProtectedHTTPMiddleware); ok {
+			h = protected.Protect(h)
+		}
+	}
+	for _, mw := range middlewares {
+		if httpMiddleware, ok := mw.(HTTPMiddleware); ok {
+			h = httpMiddleware.Wrap(h)
+		}
+	}
+	return h
+}
+
+func configLoadStatus(appConfigProvider config.AppConfigProvider) ConfigLoadStatus {
+	status, _ := appConfigProvider.(ConfigLoadStatus)
+	return status
+}
+
+func indexProgressReporters(registry *ProviderRegistry) []IndexProgressReporter {
+	var reporters []IndexProgressReporter
+	for _, p := range registry.Providers() {
+		if reporter, ok := p.(IndexProgressReporter); ok {
+			reporters = append(reporters, reporter)
+		}
+	}
+	return reporters
+}
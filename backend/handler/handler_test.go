@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"backend/domain"
+	"context"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memoryDocuments はドキュメントをメモリに保持するローカルのプロバイダー
+// ディレクトリはドキュメントのパスから求める
+type memoryDocuments struct {
+	mu    sync.Mutex
+	files map[string]string
+}
+
+var _ DirectoryProvider = (*memoryDocuments)(nil)
+var _ DocumentContentProvider = (*memoryDocuments)(nil)
+var _ DocumentContentUpdateProvider = (*memoryDocuments)(nil)
+var _ DocumentCreateProvider = (*memoryDocuments)(nil)
+var _ DocumentDeleteProvider = (*memoryDocuments)(nil)
+
+func newMemoryDocuments(files map[string]string) *memoryDocuments {
+	return &memoryDocuments{files: files}
+}
+
+func (p *memoryDocuments) GetDirectory(ctx context.Context, path string) ([]FileInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	seen := map[string]bool{}
+	items := []FileInfo{}
+	for file, content := range p.files {
+		rel, err := filepath.Rel(path, file)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		name, _, isDir := strings.Cut(rel, string(filepath.Separator))
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		item := FileInfo{Name: name, IsDir: isDir}
+		if !isDir {
+			item.Size = int64(len(content))
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil, fs.ErrNotExist
+	}
+	slices.SortFunc(items, func(a, b FileInfo) int { return strings.Compare(a.Name, b.Name) })
+	return items, nil
+}
+
+func (p *memoryDocuments) GetDocumentContent(ctx context.Context, path string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	content, ok := p.files[path]
+	if !ok {
+		return "", fs.ErrNotExist
+	}
+	return content, nil
+}
+
+func (p *memoryDocuments) UpdateDocumentContent(ctx context.Context, path string, content string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.files[path]; !ok {
+		return fs.ErrNotExist
+	}
+	p.files[path] = content
+	return nil
+}
+
+func (p *memoryDocuments) CreateDocument(ctx context.Context, path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.files[path]; ok {
+		return ErrDocumentExists
+	}
+	p.files[path] = ""
+	return nil
+}
+
+func (p *memoryDocuments) DeleteDocument(ctx context.Context, path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.files[path]; !ok {
+		return fs.ErrNotExist
+	}
+	delete(p.files, path)
+	return nil
+}
+
+func (p *memoryDocuments) exists(path string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.files[path]
+	return ok
+}
+
+// newTestRegistry はローカルのプロバイダーとしてdocumentsを登録したレジストリを返す
+func newTestRegistry(t *testing.T, documents *memoryDocuments) *ProviderRegistry {
+	t.Helper()
+	registry := NewProviderRegistry()
+	if err := registry.Register(domain.LocalRepoKind, documents); err != nil {
+		t.Fatal(err)
+	}
+	return registry
+}
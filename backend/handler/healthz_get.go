@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type GetHealthzOutput struct {
+	Body struct {
+		Status string `json:"status" example:"ok" doc:"Always 'ok' while the process is serving requests"`
+	}
+}
+
+// newHealthzHandler はプロセスが応答できるかだけを返す（死活監視用）
+func newHealthzHandler(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "get-healthz",
+		Method:      http.MethodGet,
+		Path:        "/healthz",
+		Summary:     "Liveness check",
+		Metadata:    publicOperation,
+	}, func(ctx context.Context, input *struct{}) (*GetHealthzOutput, error) {
+		resp := &GetHealthzOutput{}
+		resp.Body.Status = "ok"
+		return resp, nil
+	})
+}
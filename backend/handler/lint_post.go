@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"backend/config"
+	"backend/markdown"
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type LintInput struct {
+	Body struct {
+		Content string `json:"content,omitempty" doc:"Markdown to lint (ignored when path is given)"`
+		Path    string `json:"path,omitempty" example:"/home/user/document.md" doc:"Absolute path of the document to lint"`
+		Kind    string `json:"kind,omitempty" example:"local" doc:"Kind of document source (e.g., 'local', 'github')"`
+	}
+}
+
+type LintOutput struct {
+	Body struct {
+		Diagnostics []markdown.Diagnostic `json:"diagnostics" doc:"Problems found in the document ordered by position"`
+	}
+}
+
+// newLintHandler はMarkdownを現在のワークスペースの規則で検査する
+// pathを指定した場合は保存されているドキュメントを、指定しない場合はcontentを検査する
+func newLintHandler(api huma.API, appConfigProvider config.AppConfigProvider, registry *ProviderRegistry, sandbox *pathSandbox) {
+	huma.Post(api, "/lint", func(ctx context.Context, input *LintInput) (*LintOutput, error) {
+		content := input.Body.Content
+		if input.Body.Path != "" {
+			provider, kind, err := lookupProvider[DocumentContentProvider](ctx, registry, input.Body.Kind, CapabilityRead)
+			if err != nil {
+				return nil, err
+			}
+			if err := sandbox.checkRead(ctx, kind, input.Body.Path); err != nil {
+				return nil, err
+			}
+			content, err = provider.GetDocumentContent(ctx, input.Body.Path)
+			if err != nil {
+				logProviderError(ctx, kind, "GetDocumentContent", err)
+				return nil, huma.Error400BadRequest("Failed to read document content", err)
+			}
+		}
+
+		appConfig, err := appConfigProvider.Load(ctx)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to load configuration", err)
+		}
+
+		resp := &LintOutput{}
+		resp.Body.Diagnostics = markdown.Lint(content, LintOptionsFor(appConfig))
+		return resp, nil
+	})
+}
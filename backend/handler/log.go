@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"backend/domain"
+	"backend/logging"
+	"backend/metrics"
+	"context"
+	"log/slog"
+)
+
+// logKind はアクセスログにプロバイダーの種類を記録する
+func logKind(ctx context.Context, kind domain.RepoKind) {
+	logging.AddAttrs(ctx, slog.String("kind", kind.String()))
+}
+
+// logProviderError はプロバイダーから返されたエラーをリクエストIDとともに記録する
+func logProviderError(ctx context.Context, kind domain.RepoKind, operation string, err error) {
+	metrics.ProviderError(kind.String(), operation)
+	logging.FromContext(ctx).Error("provider error",
+		slog.String("kind", kind.String()),
+		slog.String("operation", operation),
+		slog.Any("error", err),
+	)
+}
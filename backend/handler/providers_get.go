@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type ProviderInfo struct {
+	Kind         string       `json:"kind" example:"local" doc:"Kind of document source"`
+	Capabilities []Capability `json:"capabilities" example:"[\"list\",\"read\",\"write\"]" doc:"Operations supported by the provider"`
+}
+
+type GetProvidersOutput struct {
+	Body struct {
+		Providers []ProviderInfo `json:"providers" doc:"Registered providers"`
+	}
+}
+
+func newProvidersHandler(api huma.API, registry *ProviderRegistry) {
+	huma.Get(api, "/providers", func(ctx context.Context, input *struct{}) (*GetProvidersOutput, error) {
+		resp := &GetProvidersOutput{}
+		resp.Body.Providers = []ProviderInfo{}
+		for _, kind := range registry.Kinds() {
+			resp.Body.Providers = append(resp.Body.Providers, ProviderInfo{
+				Kind:         kind.String(),
+				Capabilities: registry.Capabilities(kind),
+			})
+		}
+		return resp, nil
+	})
+}
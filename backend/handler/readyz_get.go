@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// HealthChecker はプロバイダーが依存する外部サービスなどの利用可否を確認する
+type HealthChecker interface {
+	Name() string
+	CheckHealth(ctx context.Context) error
+}
+
+// ErrHealthCheckSkipped は確認対象が設定されていないため確認しなかったことを表す
+var ErrHealthCheckSkipped = errors.New("not configured")
+
+// ConfigLoadStatus は設定の読み込み状態を返す
+type ConfigLoadStatus interface {
+	LastError() error
+	// RestartRequired は再起動しないと反映されない変更が設定にあるかを返す
+	RestartRequired() bool
+}
+
+// IndexProgress はインデックスの構築状況
+type IndexProgress struct {
+	Ready int `json:"ready" doc:"Number of directories whose index is ready"`
+	Total int `json:"total" doc:"Number of indexed directories"`
+}
+
+// IndexProgressReporter はインデックスの構築状況を返すプロバイダー
+type IndexProgressReporter interface {
+	IndexProgress() IndexProgress
+}
+
+const (
+	readyStatusReady    = "ready"
+	readyStatusDegraded = "degraded"
+	readyStatusNotReady = "not_ready"
+
+	checkStatusOK      = "ok"
+	checkStatusError   = "error"
+	checkStatusSkipped = "skipped"
+)
+
+type ReadyCheck struct {
+	Name   string `json:"name" example:"github" doc:"Name of the check"`
+	Status string `json:"status" enum:"ok,error,skipped" doc:"Result of the check"`
+	Error  string `json:"error,omitempty" doc:"Reason of the failure (only from /readyz/details)"`
+}
+
+type GetReadyzOutput struct {
+	Status int
+	Body   struct {
+		Status string     `json:"status" enum:"ready,degraded,not_ready" doc:"'not_ready' when the configuration failed to load or indexes are warming up, 'degraded' when a provider is unavailable"`
+		Config ReadyCheck `json:"config" doc:"Status of the configuration"`
+		// 再起動が必要でも処理はできるため、statusには影響させない
+		RestartRequired bool          `json:"restart_required" doc:"Whether the configuration has changes (such as plugins) that are applied only after a restart"`
+		Providers       []ReadyCheck  `json:"providers" doc:"Availability of the providers"`
+		Index           IndexProgress `json:"index" doc:"Warm-up progress of the document indexes"`
+	}
+}
+
+// healthCheckTTL は外部サービスの確認結果を再利用する時間
+// 認証なしで呼べる/readyzが外部サービスへの負荷にならないようにする
+const healthCheckTTL = 30 * time.Second
+
+// healthCheckTimeout は1つの確認にかける時間の上限
+const healthCheckTimeout = 10 * time.Second
+
+// cachedHealthChecker は確認結果をttlの間再利用する
+// 同時に呼ばれた場合も確認は1つずつ行う
+type cachedHealthChecker struct {
+	HealthChecker
+	ttl time.Duration
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	lastErr   error
+}
+
+func (c *cachedHealthChecker) CheckHealth(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.checkedAt.IsZero() && time.Since(c.checkedAt) < c.ttl {
+		return c.lastErr
+	}
+	// リクエストが中断されても確認結果は他のリクエストで使うため、キャンセルを引き継がない
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), healthCheckTimeout)
+	defer cancel()
+	c.lastErr = c.HealthChecker.CheckHealth(ctx)
+	c.checkedAt = time.Now()
+	return c.lastErr
+}
+
+// newReadyzHandler はリクエストを処理できる状態かを返す
+// 準備ができていない場合は503を返す
+// 認証なしで呼べる/readyzは状態のみを返し、エラーの内容は認証が必要な/readyz/detailsで返す
+func newReadyzHandler(api huma.API, configStatus ConfigLoadStatus, checkers []HealthChecker, indexes []IndexProgressReporter) {
+	cached := make([]HealthChecker, len(checkers))
+	for i, checker := range checkers {
+		cached[i] = &cachedHealthChecker{HealthChecker: checker, ttl: healthCheckTTL}
+	}
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-readyz",
+		Method:      http.MethodGet,
+		Path:        "/readyz",
+		Summary:     "Readiness check",
+		Metadata:    publicOperation,
+	}, func(ctx context.Context, input *struct{}) (*GetReadyzOutput, error) {
+		return readiness(ctx, configStatus, cached, indexes, false), nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-readyz-details",
+		Method:      http.MethodGet,
+		Path:        "/readyz/details",
+		Summary:     "Readiness check with error details",
+	}, func(ctx context.Context, input *struct{}) (*GetReadyzOutput, error) {
+		return readiness(ctx, configStatus, cached, indexes, true), nil
+	})
+}
+
+// readiness は設定、プロバイダー、インデックスの状態をまとめる
+// detailsがfalseの場合は設定のパスや接続先などを含むエラーの内容を返さない
+func readiness(ctx context.Context, configStatus ConfigLoadStatus, checkers []HealthChecker, indexes []IndexProgressReporter, details bool) *GetReadyzOutput {
+	resp := &GetReadyzOutput{}
+	ready, degraded := true, false
+	errorText := func(err error) string {
+		if !details {
+			return ""
+		}
+		return err.Error()
+	}
+
+	resp.Body.Config = ReadyCheck{Name: "config", Status: checkStatusOK}
+	if configStatus != nil {
+		if err := configStatus.LastError(); err != nil {
+			resp.Body.Config.Status = checkStatusError
+			resp.Body.Config.Error = errorText(err)
+			ready = false
+		}
+		resp.Body.RestartRequired = configStatus.RestartRequired()
+	}
+
+	resp.Body.Providers = make([]ReadyCheck, 0, len(checkers))
+	for _, checker := range checkers {
+		check := ReadyCheck{Name: checker.Name(), Status: checkStatusOK}
+		if err := checker.CheckHealth(ctx); errors.Is(err, ErrHealthCheckSkipped) {
+			check.Status = checkStatusSkipped
+		} else if err != nil {
+			check.Status = checkStatusError
+			check.Error = errorText(err)
+			degraded = true
+		}
+		resp.Body.Providers = append(resp.Body.Providers, check)
+	}
+
+	for _, reporter := range indexes {
+		progress := reporter.IndexProgress()
+		resp.Body.Index.Ready += progress.Ready
+		resp.Body.Index.Total += progress.Total
+	}
+	if resp.Body.Index.Ready < resp.Body.Index.Total {
+		ready = false
+	}
+
+	switch {
+	case !ready:
+		resp.Status = http.StatusServiceUnavailable
+		resp.Body.Status = readyStatusNotReady
+	case degraded:
+		resp.Status = http.StatusOK
+		resp.Body.Status = readyStatusDegraded
+	default:
+		resp.Status = http.StatusOK
+		resp.Body.Status = readyStatusReady
+	}
+	return resp
+}
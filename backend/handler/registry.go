@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"backend/domain"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// Capability はプロバイダーが実装している操作
+type Capability string
+
+const (
+	CapabilityList   Capability = "list"   // DocumentsProvider
+	CapabilityBrowse Capability = "browse" // DirectoryProvider
+	CapabilityRead   Capability = "read"   // DocumentContentProvider
+	CapabilityWrite  Capability = "write"  // DocumentContentUpdateProvider
+	CapabilityCreate Capability = "create" // DocumentCreateProvider
+	CapabilityDelete Capability = "delete" // DocumentDeleteProvider
+)
+
+// capabilityChecks は各Capabilityに対応するインターフェースを実装しているかを判定する
+var capabilityChecks = []struct {
+	capability Capability
+	implements func(provider any) bool
+}{
+	{CapabilityList, implements[DocumentsProvider]},
+	{CapabilityBrowse, implements[DirectoryProvider]},
+	{CapabilityRead, implements[DocumentContentProvider]},
+	{CapabilityWrite, implements[DocumentContentUpdateProvider]},
+	{CapabilityCreate, implements[DocumentCreateProvider]},
+	{CapabilityDelete, implements[DocumentDeleteProvider]},
+}
+
+func implements[P any](provider any) bool {
+	_, ok := provider.(P)
+	return ok
+}
+
+// CapabilityReporter は実装しているインターフェースの一部のみを提供するプロバイダー
+// プラグインのように実行時に操作が決まるプロバイダーが実装する
+type CapabilityReporter interface {
+	Capabilities() []Capability
+}
+
+func supports(provider any, capability Capability) bool {
+	reporter, ok := provider.(CapabilityReporter)
+	if !ok {
+		return true
+	}
+	return slices.Contains(reporter.Capabilities(), capability)
+}
+
+// ProviderRegistry はRepoKindごとに1つのプロバイダーを保持する
+// プロバイダーが実装しているインターフェースから利用できる操作を判定する
+type ProviderRegistry struct {
+	mu        sync.RWMutex
+	kinds     []domain.RepoKind
+	providers map[domain.RepoKind]any
+}
+
+func NewProviderRegistry() *ProviderRegistry {
+	return &ProviderRegistry{
+		providers: map[domain.RepoKind]any{},
+	}
+}
+
+// Register はkindのプロバイダーを登録する
+// 同じkindを複数回登録することはできない
+func (r *ProviderRegistry) Register(kind domain.RepoKind, provider any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.providers[kind]; ok {
+		return fmt.Errorf("provider for kind %s is already registered", kind)
+	}
+	r.kinds = append(r.kinds, kind)
+	r.providers[kind] = provider
+	return nil
+}
+
+// Kinds は登録順にkindを返す
+func (r *ProviderRegistry) Kinds() []domain.RepoKind {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.RepoKind{}, r.kinds...)
+}
+
+// Provider はkindのプロバイダーを返す
+func (r *ProviderRegistry) Provider(kind domain.RepoKind) (any, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	provider, ok := r.providers[kind]
+	return provider, ok
+}
+
+// Providers は登録順にプロバイダーを返す
+func (r *ProviderRegistry) Providers() []any {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	providers := make([]any, 0, len(r.kinds))
+	for _, kind := range r.kinds {
+		providers = append(providers, r.providers[kind])
+	}
+	return providers
+}
+
+// Capabilities はkindのプロバイダーが実装している操作を返す
+func (r *ProviderRegistry) Capabilities(kind domain.RepoKind) []Capability {
+	provider, ok := r.Provider(kind)
+	if !ok {
+		return nil
+	}
+
+	capabilities := []Capability{}
+	for _, check := range capabilityChecks {
+		if check.implements(provider) && supports(provider, check.capability) {
+			capabilities = append(capabilities, check.capability)
+		}
+	}
+	return capabilities
+}
+
+// UnknownKindError はkindが不正かプロバイダーが登録されていないことを表す
+type UnknownKindError struct {
+	Kind string
+}
+
+func (e *UnknownKindError) Error() string {
+	return fmt.Sprintf("no provider registered for kind: %s", e.Kind)
+}
+
+// UnsupportedCapabilityError はプロバイダーが操作を実装していないことを表す
+type UnsupportedCapabilityError struct {
+	Kind       domain.RepoKind
+	Capability Capability
+}
+
+func (e *UnsupportedCapabilityError) Error() string {
+	return fmt.Sprintf("provider for kind %s does not support %s", e.Kind, e.Capability)
+}
+
+// FindProvider はkindのプロバイダーをPとして返す
+func FindProvider[P any](r *ProviderRegistry, value string, capability Capability) (P, domain.RepoKind, error) {
+	var zero P
+
+	kind, err := domain.ParseRepoKind(value)
+	if err != nil {
+		return zero, domain.RepoKind{}, &UnknownKindError{Kind: value}
+	}
+	provider, ok := r.Provider(kind)
+	if !ok {
+		return zero, kind, &UnknownKindError{Kind: value}
+	}
+	p, ok := provider.(P)
+	if !ok || !supports(provider, capability) {
+		return zero, kind, &UnsupportedCapabilityError{Kind: kind, Capability: capability}
+	}
+	return p, kind, nil
+}
+
+// lookupProvider はFindProviderのエラーをHTTPのステータスに変換する
+// kindが不明な場合は400、操作を実装していない場合は501を返す
+func lookupProvider[P any](ctx context.Context, r *ProviderRegistry, value string, capability Capability) (P, domain.RepoKind, error) {
+	p, kind, err := FindProvider[P](r, value, capability)
+	if kind != (domain.RepoKind{}) {
+		logKind(ctx, kind)
+	}
+
+	var unknownKind *UnknownKindError
+	var unsupported *UnsupportedCapabilityError
+	switch {
+	case errors.As(err, &unknownKind):
+		return p, kind, huma.Error400BadRequest(err.Error(), err)
+	case errors.As(err, &unsupported):
+		return p, kind, huma.Error501NotImplemented(err.Error(), err)
+	}
+	return p, kind, err
+}
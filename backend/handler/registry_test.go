@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"backend/domain"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+)
+
+// readOnlyDocuments は書き込み操作を実装しているが読み取りのみを提供するプロバイダー
+type readOnlyDocuments struct {
+	*memoryDocuments
+}
+
+func (readOnlyDocuments) Capabilities() []Capability {
+	return []Capability{CapabilityBrowse, CapabilityRead}
+}
+
+func newCapabilityTestRegistry(t *testing.T) *ProviderRegistry {
+	t.Helper()
+	registry := newTestRegistry(t, newMemoryDocuments(map[string]string{"/notes/a.md": "a"}))
+	if err := registry.Register(domain.ArchiveRepoKind, readOnlyDocuments{newMemoryDocuments(map[string]string{})}); err != nil {
+		t.Fatal(err)
+	}
+	return registry
+}
+
+func TestProviderRegistryRegister(t *testing.T) {
+	registry := newCapabilityTestRegistry(t)
+	if err := registry.Register(domain.LocalRepoKind, newMemoryDocuments(nil)); err == nil {
+		t.Error("registering the same kind twice succeeded")
+	}
+	if got, want := registry.Kinds(), []domain.RepoKind{domain.LocalRepoKind, domain.ArchiveRepoKind}; !slices.Equal(got, want) {
+		t.Errorf("kinds = %v, want %v", got, want)
+	}
+	if got := len(registry.Providers()); got != 2 {
+		t.Errorf("providers = %d, want 2", got)
+	}
+}
+
+func TestProviderRegistryCapabilities(t *testing.T) {
+	registry := newCapabilityTestRegistry(t)
+	tests := []struct {
+		kind domain.RepoKind
+		want []Capability
+	}{
+		// memoryDocumentsはDocumentsProviderを実装していない
+		{domain.LocalRepoKind, []Capability{CapabilityBrowse, CapabilityRead, CapabilityWrite, CapabilityCreate, CapabilityDelete}},
+		{domain.ArchiveRepoKind, []Capability{CapabilityBrowse, CapabilityRead}},
+		{domain.S3RepoKind, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind.String(), func(t *testing.T) {
+			if got := registry.Capabilities(tt.kind); !slices.Equal(got, tt.want) {
+				t.Errorf("capabilities = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// findAndLookup はFindProviderのエラーとlookupProviderのエラーを返す
+func findAndLookup[P any](registry *ProviderRegistry) func(kind string, capability Capability) (error, error) {
+	return func(kind string, capability Capability) (error, error) {
+		_, _, findErr := FindProvider[P](registry, kind, capability)
+		_, _, lookupErr := lookupProvider[P](context.Background(), registry, kind, capability)
+		return findErr, lookupErr
+	}
+}
+
+func TestLookupProvider(t *testing.T) {
+	registry := newCapabilityTestRegistry(t)
+	tests := []struct {
+		name       string
+		kind       string
+		capability Capability
+		lookup     func(kind string, capability Capability) (error, error)
+		wantErr    error
+		wantStatus int
+	}{
+		{"supported", "local", CapabilityWrite, findAndLookup[DocumentContentUpdateProvider](registry), nil, http.StatusOK},
+		{"invalid kind", "nope", CapabilityRead, findAndLookup[DocumentContentProvider](registry), &UnknownKindError{}, http.StatusBadRequest},
+		{"kind without a provider", "s3", CapabilityRead, findAndLookup[DocumentContentProvider](registry), &UnknownKindError{}, http.StatusBadRequest},
+		{"interface is not implemented", "local", CapabilityList, findAndLookup[DocumentsProvider](registry), &UnsupportedCapabilityError{}, http.StatusNotImplemented},
+		{"capability is not reported", "archive", CapabilityWrite, findAndLookup[DocumentContentUpdateProvider](registry), &UnsupportedCapabilityError{}, http.StatusNotImplemented},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findErr, lookupErr := tt.lookup(tt.kind, tt.capability)
+			switch tt.wantErr.(type) {
+			case nil:
+				if findErr != nil {
+					t.Errorf("error = %v", findErr)
+				}
+			case *UnknownKindError:
+				var target *UnknownKindError
+				if !errors.As(findErr, &target) || target.Kind != tt.kind {
+					t.Errorf("error = %v, want UnknownKindError for %s", findErr, tt.kind)
+				}
+			case *UnsupportedCapabilityError:
+				var target *UnsupportedCapabilityError
+				if !errors.As(findErr, &target) || target.Capability != tt.capability {
+					t.Errorf("error = %v, want UnsupportedCapabilityError for %s", findErr, tt.capability)
+				}
+			}
+			if got := errorStatus(t, lookupErr); got != tt.wantStatus {
+				t.Errorf("status = %d, want %d", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestProvidersHandler(t *testing.T) {
+	_, api := humatest.New(t)
+	newProvidersHandler(api, newCapabilityTestRegistry(t))
+
+	resp := api.Get("/providers")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d", resp.Code)
+	}
+	var body struct {
+		Providers []ProviderInfo `json:"providers"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Providers) != 2 || body.Providers[0].Kind != "local" || body.Providers[1].Kind != "archive" {
+		t.Fatalf("providers = %+v", body.Providers)
+	}
+	if got, want := body.Providers[1].Capabilities, []Capability{CapabilityBrowse, CapabilityRead}; !slices.Equal(got, want) {
+		t.Errorf("archive capabilities = %v, want %v", got, want)
+	}
+}
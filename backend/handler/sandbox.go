@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"backend/config"
+	"backend/domain"
+	"backend/util"
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// pathSandbox はwebモードでローカルファイルへのアクセスを制限する
+// 読み取りは許可されたディレクトリ（AllowedRoots）配下、
+// 書き込みはユーザーが設定したディレクトリ（Directories）配下のみ許可する
+type pathSandbox struct {
+	enabled           bool
+	appConfigProvider config.AppConfigProvider
+}
+
+func newPathSandbox(appMode config.AppMode, appConfigProvider config.AppConfigProvider) *pathSandbox {
+	return &pathSandbox{
+		enabled:           appMode == config.Web,
+		appConfigProvider: appConfigProvider,
+	}
+}
+
+func (s *pathSandbox) checkRead(ctx context.Context, kind domain.RepoKind, path string) error {
+	if !s.enabled || !isLocalPathKind(kind) {
+		return nil
+	}
+	appConfig, err := s.appConfigProvider.Load(ctx)
+	if err != nil {
+		return huma.Error500InternalServerError("Failed to load configuration", err)
+	}
+	if !util.PathWithin(path, appConfig.LocalFile.AllowedRoots) {
+		return huma.Error403Forbidden("Path is outside of the allowed directories")
+	}
+	return nil
+}
+
+func (s *pathSandbox) checkWrite(ctx context.Context, kind domain.RepoKind, path string) error {
+	if !s.enabled || !isLocalPathKind(kind) {
+		return nil
+	}
+	appConfig, err := s.appConfigProvider.Load(ctx)
+	if err != nil {
+		return huma.Error500InternalServerError("Failed to load configuration", err)
+	}
+	if !util.PathWithin(path, appConfig.LocalFile.Directories) {
+		return huma.Error403Forbidden("Path is outside of the configured directories")
+	}
+	return nil
+}
+
+// isLocalPathKind はkindのパスがサーバーのローカルのパスかを返す
+func isLocalPathKind(kind domain.RepoKind) bool {
+	return kind == domain.LocalRepoKind || kind == domain.ArchiveRepoKind
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"backend/config"
+	"backend/config/configtest"
+	"backend/domain"
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// failingConfigProvider は常に読み込みに失敗する
+type failingConfigProvider struct{}
+
+func (failingConfigProvider) Load(ctx context.Context) (*config.AppConfig, error) {
+	return nil, errors.New("broken config")
+}
+
+func (failingConfigProvider) Save(ctx context.Context, appConfig *config.AppConfig) error {
+	return errors.New("broken config")
+}
+
+// errorStatus はhumaのエラーのステータスを返す（エラーが無い場合は200）
+func errorStatus(t *testing.T, err error) int {
+	t.Helper()
+	if err == nil {
+		return http.StatusOK
+	}
+	var statusErr huma.StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("error is not a status error: %v", err)
+	}
+	return statusErr.GetStatus()
+}
+
+func TestPathSandbox(t *testing.T) {
+	dir := t.TempDir()
+	allowed := filepath.Join(dir, "allowed")
+	docs := filepath.Join(allowed, "docs")
+	appConfig := &config.AppConfig{}
+	appConfig.LocalFile.AllowedRoots = []string{allowed}
+	appConfig.LocalFile.Directories = []string{docs}
+
+	tests := []struct {
+		name      string
+		appMode   config.AppMode
+		kind      domain.RepoKind
+		path      string
+		wantRead  int
+		wantWrite int
+	}{
+		{"configured directory", config.Web, domain.LocalRepoKind, filepath.Join(docs, "a.md"), http.StatusOK, http.StatusOK},
+		{"allowed root is read only", config.Web, domain.LocalRepoKind, filepath.Join(allowed, "a.md"), http.StatusOK, http.StatusForbidden},
+		{"outside of the allowed roots", config.Web, domain.LocalRepoKind, filepath.Join(dir, "other", "a.md"), http.StatusForbidden, http.StatusForbidden},
+		{"sibling with the same prefix", config.Web, domain.LocalRepoKind, allowed + "-private/a.md", http.StatusForbidden, http.StatusForbidden},
+		{"parent reference", config.Web, domain.LocalRepoKind, docs + "/../../other/a.md", http.StatusForbidden, http.StatusForbidden},
+		{"archive paths are local", config.Web, domain.ArchiveRepoKind, filepath.Join(dir, "other.zip"), http.StatusForbidden, http.StatusForbidden},
+		{"remote providers are not restricted", config.Web, domain.S3RepoKind, "/anywhere/a.md", http.StatusOK, http.StatusOK},
+		{"native mode is not restricted", config.Native, domain.LocalRepoKind, filepath.Join(dir, "other", "a.md"), http.StatusOK, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sandbox := newPathSandbox(tt.appMode, configtest.NewProvider(appConfig))
+			if got := errorStatus(t, sandbox.checkRead(context.Background(), tt.kind, tt.path)); got != tt.wantRead {
+				t.Errorf("checkRead = %d, want %d", got, tt.wantRead)
+			}
+			if got := errorStatus(t, sandbox.checkWrite(context.Background(), tt.kind, tt.path)); got != tt.wantWrite {
+				t.Errorf("checkWrite = %d, want %d", got, tt.wantWrite)
+			}
+		})
+	}
+}
+
+func TestPathSandboxFailsClosedWhenConfigCannotBeLoaded(t *testing.T) {
+	sandbox := newPathSandbox(config.Web, failingConfigProvider{})
+	if got := errorStatus(t, sandbox.checkRead(context.Background(), domain.LocalRepoKind, "/a.md")); got != http.StatusInternalServerError {
+		t.Errorf("checkRead = %d, want 500", got)
+	}
+	if got := errorStatus(t, sandbox.checkWrite(context.Background(), domain.LocalRepoKind, "/a.md")); got != http.StatusInternalServerError {
+		t.Errorf("checkWrite = %d, want 500", got)
+	}
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"backend/metrics"
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// static/dist にはフロントエンドのビルド成果物（pnpm build）が出力される
+// ビルドされていない場合は static/index.html のテストページを配信する
+//
+//go:embed all:static
+var staticFiles embed.FS
+
+// ファイル名にハッシュを含むビルド成果物のディレクトリ
+const hashedAssetsDir = "assets/"
+
+type spaHandler struct {
+	files fs.FS
+	index []byte
+}
+
+// newSPAHandler はフロントエンドを配信するハンドラーを返す
+// 存在しないパス（/browse/... など）はクライアント側でルーティングするためindex.htmlを返す
+func newSPAHandler() (http.Handler, error) {
+	dist, err := fs.Sub(staticFiles, "static/dist")
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := fs.ReadFile(dist, "index.html")
+	if err != nil {
+		// フロントエンドがビルドされていない場合
+		dist = nil
+		index, err = staticFiles.ReadFile("static/index.html")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &spaHandler{
+		files: dist,
+		index: index,
+	}, nil
+}
+
+func (h *spaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if h.files != nil && name != "" && name != "index.html" {
+		if info, err := fs.Stat(h.files, name); err == nil && !info.IsDir() {
+			if strings.HasPrefix(name, hashedAssetsDir) {
+				w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+			} else {
+				w.Header().Set("Cache-Control", "public, max-age=3600")
+			}
+			metrics.StaticRequest("asset")
+			http.ServeFileFS(w, r, h.files, name)
+			return
+		}
+
+		// 存在しないビルド成果物はindex.htmlではなく404を返す
+		// それ以外はクライアント側のルート（/browse/docs.v2 など、.を含むものもある）としてindex.htmlを返す
+		if strings.HasPrefix(name, hashedAssetsDir) {
+			metrics.StaticRequest("not_found")
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	metrics.StaticRequest("index")
+
+	// index.htmlは常に最新のアセットを参照させるためキャッシュさせない
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(h.index)
+}
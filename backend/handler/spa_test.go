@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSPAHandler(t *testing.T) {
+	h := &spaHandler{
+		files: fstest.MapFS{
+			"index.html":           {Data: []byte("index")},
+			"favicon.ico":          {Data: []byte("icon")},
+			"assets/index-abc.js":  {Data: []byte("script")},
+			"assets/fonts/a.woff2": {Data: []byte("font")},
+		},
+		index: []byte("index"),
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		want         int
+		body         string
+		cacheControl string
+	}{
+		{"root", http.MethodGet, "/", http.StatusOK, "index", "no-cache"},
+		{"index.html", http.MethodGet, "/index.html", http.StatusOK, "index", "no-cache"},
+		{"hashed asset", http.MethodGet, "/assets/index-abc.js", http.StatusOK, "script", "public, max-age=31536000, immutable"},
+		{"static file", http.MethodGet, "/favicon.ico", http.StatusOK, "icon", "public, max-age=3600"},
+		{"missing hashed asset", http.MethodGet, "/assets/index-old.js", http.StatusNotFound, "", ""},
+		{"client route", http.MethodGet, "/browse/notes", http.StatusOK, "index", "no-cache"},
+		{"client route with a dotted directory", http.MethodGet, "/browse/github.com%2Fowner%2Fdocs.v2", http.StatusOK, "index", "no-cache"},
+		{"client route with a file name", http.MethodGet, "/browse/notes/readme.md", http.StatusOK, "index", "no-cache"},
+		{"directory", http.MethodGet, "/assets", http.StatusOK, "index", "no-cache"},
+		{"head", http.MethodHead, "/browse/docs.v2", http.StatusOK, "", "no-cache"},
+		{"post", http.MethodPost, "/", http.StatusMethodNotAllowed, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.body)
+			}
+			if got := w.Header().Get("Cache-Control"); tt.cacheControl != "" && got != tt.cacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.cacheControl)
+			}
+		})
+	}
+}
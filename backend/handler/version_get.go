@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"backend/buildinfo"
+	"context"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type GetVersionOutput struct {
+	Body buildinfo.Info
+}
+
+func newVersionHandler(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "get-version",
+		Method:      http.MethodGet,
+		Path:        "/version",
+		Summary:     "Build version",
+		Metadata:    publicOperation,
+	}, func(ctx context.Context, input *struct{}) (*GetVersionOutput, error) {
+		return &GetVersionOutput{Body: buildinfo.Get()}, nil
+	})
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"backend/config"
+	"backend/domain"
+	"context"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// WorkspaceRoot はワークスペースを構成するドキュメントの取得元
+type WorkspaceRoot struct {
+	Kind string `json:"kind" example:"local" doc:"Kind of document source (e.g., 'local', 'github')"`
+	Path string `json:"path" example:"/home/user/repo" doc:"Root path of the document source"`
+}
+
+type WorkspaceDocument struct {
+	domain.Document
+	Root string `json:"root" example:"/home/user/repo" doc:"Root path the document belongs to"`
+	Kind string `json:"kind" example:"local" doc:"Kind of document source"`
+}
+
+type WorkspaceRootError struct {
+	WorkspaceRoot
+	Message string `json:"message" doc:"Reason why documents could not be read from the root"`
+}
+
+type GetWorkspaceDocumentsInput struct {
+	Query string `query:"q" example:"readme" doc:"Case-insensitive filter applied to document names"`
+}
+
+type GetWorkspaceDocumentsOutput struct {
+	Body struct {
+		Roots     []WorkspaceRoot      `json:"roots" doc:"Document sources included in the workspace"`
+		Documents []WorkspaceDocument  `json:"documents" doc:"Documents found across all roots"`
+		Errors    []WorkspaceRootError `json:"errors" doc:"Roots that could not be read"`
+	}
+}
+
+// workspaceRoots は現在のワークスペースの取得元を組み立てる
+// GitHubのリポジトリはGitHub用のプロバイダーが登録されている場合のみ含める
+func workspaceRoots(appConfig *config.AppConfig, registry *ProviderRegistry) []WorkspaceRoot {
+	roots := make([]WorkspaceRoot, 0, len(appConfig.LocalFile.Directories))
+	for _, dir := range appConfig.LocalFile.Directories {
+		roots = append(roots, WorkspaceRoot{
+			Kind: domain.LocalRepoKind.String(),
+			Path: dir,
+		})
+	}
+	if _, ok := registry.Provider(domain.GithubRepoKind); !ok {
+		return roots
+	}
+	if w := appConfig.ActiveWorkspace(); w != nil {
+		for _, repo := range w.GithubRepos {
+			roots = append(roots, WorkspaceRoot{
+				Kind: domain.GithubRepoKind.String(),
+				Path: repo,
+			})
+		}
+	}
+	return roots
+}
+
+func newWorkspaceDocumentsHandler(api huma.API, appConfigProvider config.AppConfigProvider, registry *ProviderRegistry) {
+	huma.Get(api, "/workspace/documents", func(ctx context.Context, input *GetWorkspaceDocumentsInput) (*GetWorkspaceDocumentsOutput, error) {
+		appConfig, err := appConfigProvider.Load(ctx)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to load configuration", err)
+		}
+
+		resp := &GetWorkspaceDocumentsOutput{}
+		resp.Body.Roots = workspaceRoots(appConfig, registry)
+		resp.Body.Documents = []WorkspaceDocument{}
+		resp.Body.Errors = []WorkspaceRootError{}
+
+		query := strings.ToLower(input.Query)
+		for _, root := range resp.Body.Roots {
+			docs, err := getRootDocuments(ctx, registry, root, DocumentConditionFor(appConfig))
+			if err != nil {
+				// 1つの取得元が読めなくても他の取得元の結果は返す
+				resp.Body.Errors = append(resp.Body.Errors, WorkspaceRootError{
+					WorkspaceRoot: root,
+					Message:       err.Error(),
+				})
+				continue
+			}
+
+			for _, doc := range docs {
+				if query != "" && !strings.Contains(strings.ToLower(doc.Name), query) {
+					continue
+				}
+				resp.Body.Documents = append(resp.Body.Documents, WorkspaceDocument{
+					Document: doc,
+					Root:     root.Path,
+					Kind:     root.Kind,
+				})
+			}
+		}
+
+		return resp, nil
+	})
+}
+
+func getRootDocuments(ctx context.Context, registry *ProviderRegistry, root WorkspaceRoot, condition DocumentCondition) ([]domain.Document, error) {
+	provider, _, err := FindProvider[DocumentsProvider](registry, root.Kind, CapabilityList)
+	if err != nil {
+		return nil, err
+	}
+	return provider.GetDocuments(ctx, root.Path, condition)
+}
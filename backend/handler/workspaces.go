@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"backend/config"
+	"backend/markdown"
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type WorkspacesOutput struct {
+	Body struct {
+		Active     string             `json:"active" example:"default" doc:"Name of the active workspace"`
+		Workspaces []config.Workspace `json:"workspaces" doc:"Configured workspaces"`
+	}
+}
+
+type CreateWorkspaceInput struct {
+	Body config.Workspace
+}
+
+type SwitchWorkspaceInput struct {
+	Body struct {
+		Name string `json:"name" example:"work" doc:"Name of the workspace to activate"`
+	}
+}
+
+type DeleteWorkspaceInput struct {
+	Name string `path:"name" example:"work" doc:"Name of the workspace to delete"`
+}
+
+// DocumentConditionFor は現在のワークスペースの探索条件を返す
+// 条件が設定されていない項目は既定の条件を使用する
+func DocumentConditionFor(appConfig *config.AppConfig) DocumentCondition {
+	condition := DefaultDocumentCondition()
+
+	w := appConfig.ActiveWorkspace()
+	if w == nil {
+		return condition
+	}
+	if len(w.Discovery.IncludeExts) > 0 {
+		condition.Includes.Exts = w.Discovery.IncludeExts
+	}
+	if len(w.Discovery.ExcludeDirNames) > 0 {
+		condition.Excludes.DirNames = w.Discovery.ExcludeDirNames
+	}
+	return condition
+}
+
+// LintOptionsFor は現在のワークスペースのリンターの設定を返す
+func LintOptionsFor(appConfig *config.AppConfig) markdown.LintOptions {
+	w := appConfig.ActiveWorkspace()
+	if w == nil {
+		return markdown.LintOptions{}
+	}
+	return w.Lint.Options()
+}
+
+func newWorkspacesOutput(appConfig *config.AppConfig) *WorkspacesOutput {
+	resp := &WorkspacesOutput{}
+	resp.Body.Active = appConfig.ActiveWorkspaceName
+	resp.Body.Workspaces = appConfig.Workspaces
+	if resp.Body.Workspaces == nil {
+		resp.Body.Workspaces = []config.Workspace{}
+	}
+	return resp
+}
+
+// saveWorkspaces はワークスペースの変更を保存し、保存後の設定を返す
+func saveWorkspaces(ctx context.Context, provider config.AppConfigProvider, appConfig *config.AppConfig) (*WorkspacesOutput, error) {
+	err := provider.Save(ctx, appConfig)
+	var validationErr *config.ValidationError
+	if errors.As(err, &validationErr) {
+		return nil, huma.Error400BadRequest("Invalid workspace", err)
+	}
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to save configuration", err)
+	}
+	return newWorkspacesOutput(appConfig), nil
+}
+
+func newWorkspacesHandler(api huma.API, provider config.AppConfigProvider) {
+	huma.Get(api, "/workspaces", func(ctx context.Context, input *struct{}) (*WorkspacesOutput, error) {
+		appConfig, err := provider.Load(ctx)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to load configuration", err)
+		}
+		return newWorkspacesOutput(appConfig), nil
+	})
+
+	huma.Post(api, "/workspaces", func(ctx context.Context, input *CreateWorkspaceInput) (*WorkspacesOutput, error) {
+		appConfig, err := provider.Load(ctx)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to load configuration", err)
+		}
+		if appConfig.FindWorkspace(input.Body.Name) >= 0 {
+			return nil, huma.Error409Conflict("Workspace already exists")
+		}
+
+		updated := *appConfig
+		updated.Workspaces = slices.Clone(appConfig.Workspaces)
+		updated.SyncActiveWorkspace()
+		updated.Workspaces = append(updated.Workspaces, input.Body)
+		return saveWorkspaces(ctx, provider, &updated)
+	})
+
+	huma.Put(api, "/workspaces/active", func(ctx context.Context, input *SwitchWorkspaceInput) (*WorkspacesOutput, error) {
+		appConfig, err := provider.Load(ctx)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to load configuration", err)
+		}
+		if appConfig.FindWorkspace(input.Body.Name) < 0 {
+			return nil, huma.Error404NotFound("Workspace does not exist")
+		}
+
+		updated := *appConfig
+		updated.ActiveWorkspaceName = input.Body.Name
+		updated.ApplyActiveWorkspace()
+		return saveWorkspaces(ctx, provider, &updated)
+	})
+
+	huma.Delete(api, "/workspaces/{name}", func(ctx context.Context, input *DeleteWorkspaceInput) (*WorkspacesOutput, error) {
+		appConfig, err := provider.Load(ctx)
+		if err != nil {
+			return nil, huma.Error500InternalServerError("Failed to load configuration", err)
+		}
+		i := appConfig.FindWorkspace(input.Name)
+		if i < 0 {
+			return nil, huma.Error404NotFound("Workspace does not exist")
+		}
+		if input.Name == appConfig.ActiveWorkspaceName {
+			return nil, huma.Error400BadRequest("Cannot delete the active workspace")
+		}
+
+		updated := *appConfig
+		updated.Workspaces = slices.Delete(slices.Clone(updated.Workspaces), i, i+1)
+		return saveWorkspaces(ctx, provider, &updated)
+	})
+}
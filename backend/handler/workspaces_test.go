@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"backend/config"
+	"backend/config/configtest"
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+)
+
+// validatingConfigProvider は保存前に設定を検証する（config.Storeと同じ）
+type validatingConfigProvider struct {
+	*configtest.Provider
+}
+
+func (p validatingConfigProvider) Save(ctx context.Context, appConfig *config.AppConfig) error {
+	if err := appConfig.Validate(); err != nil {
+		return err
+	}
+	return p.Provider.Save(ctx, appConfig)
+}
+
+func newTestWorkspaces(t *testing.T) (humatest.TestAPI, *configtest.Provider, string) {
+	t.Helper()
+	dir := t.TempDir()
+	appConfig := &config.AppConfig{
+		ActiveWorkspaceName: "default",
+		Workspaces: []config.Workspace{
+			{Name: "default", Directories: []string{dir}},
+			{Name: "work", Directories: []string{t.TempDir()}},
+		},
+	}
+	appConfig.ApplyActiveWorkspace()
+	provider := configtest.NewProvider(appConfig)
+
+	_, api := humatest.New(t)
+	newWorkspacesHandler(api, validatingConfigProvider{provider})
+	return api, provider, dir
+}
+
+func TestWorkspacesHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       any
+		want       int
+		wantActive string
+		wantNames  []string
+	}{
+		{"list", http.MethodGet, "/workspaces", nil, http.StatusOK, "default", []string{"default", "work"}},
+		{"create", http.MethodPost, "/workspaces", map[string]any{"name": "notes"}, http.StatusOK, "default", []string{"default", "work", "notes"}},
+		{"create an existing workspace", http.MethodPost, "/workspaces", map[string]any{"name": "work"}, http.StatusConflict, "default", []string{"default", "work"}},
+		{"create an invalid workspace", http.MethodPost, "/workspaces", map[string]any{"name": ""}, http.StatusBadRequest, "default", []string{"default", "work"}},
+		{"switch", http.MethodPut, "/workspaces/active", map[string]any{"name": "work"}, http.StatusOK, "work", []string{"default", "work"}},
+		{"switch to a missing workspace", http.MethodPut, "/workspaces/active", map[string]any{"name": "missing"}, http.StatusNotFound, "default", []string{"default", "work"}},
+		{"delete", http.MethodDelete, "/workspaces/work", nil, http.StatusOK, "default", []string{"default"}},
+		{"delete the active workspace", http.MethodDelete, "/workspaces/default", nil, http.StatusBadRequest, "default", []string{"default", "work"}},
+		{"delete a missing workspace", http.MethodDelete, "/workspaces/missing", nil, http.StatusNotFound, "default", []string{"default", "work"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, provider, _ := newTestWorkspaces(t)
+			var args []any
+			if tt.body != nil {
+				args = append(args, tt.body)
+			}
+			resp := api.Do(tt.method, tt.path, args...)
+			if resp.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", resp.Code, tt.want, resp.Body.String())
+			}
+
+			saved, _ := provider.Load(context.Background())
+			var names []string
+			for _, w := range saved.Workspaces {
+				names = append(names, w.Name)
+			}
+			if saved.ActiveWorkspaceName != tt.wantActive || !slices.Equal(names, tt.wantNames) {
+				t.Errorf("saved = %s %v, want %s %v", saved.ActiveWorkspaceName, names, tt.wantActive, tt.wantNames)
+			}
+			if resp.Code != http.StatusOK {
+				return
+			}
+			var body struct {
+				Active     string             `json:"active"`
+				Workspaces []config.Workspace `json:"workspaces"`
+			}
+			if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Active != tt.wantActive || len(body.Workspaces) != len(tt.wantNames) {
+				t.Errorf("response = %+v", body)
+			}
+		})
+	}
+}
+
+func TestSwitchWorkspaceAppliesDirectories(t *testing.T) {
+	api, provider, defaultDir := newTestWorkspaces(t)
+
+	if resp := api.Put("/workspaces/active", map[string]any{"name": "work"}); resp.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.Code, resp.Body.String())
+	}
+	saved, _ := provider.Load(context.Background())
+	if want := saved.Workspaces[1].Directories; !slices.Equal(saved.LocalFile.Directories, want) {
+		t.Errorf("directories = %v, want %v", saved.LocalFile.Directories, want)
+	}
+	// 切り替え前のワークスペースの内容は変更しない
+	if got := saved.Workspaces[0].Directories; !slices.Equal(got, []string{defaultDir}) {
+		t.Errorf("default directories = %v, want %v", got, []string{defaultDir})
+	}
+}
+
+func TestDocumentConditionFor(t *testing.T) {
+	appConfig := &config.AppConfig{
+		ActiveWorkspaceName: "notes",
+		Workspaces: []config.Workspace{
+			{Name: "default"},
+			{Name: "notes", Discovery: config.DiscoveryRules{IncludeExts: []string{"txt"}}},
+		},
+	}
+	condition := DocumentConditionFor(appConfig)
+	if !slices.Equal(condition.Includes.Exts, []string{"txt"}) {
+		t.Errorf("include exts = %v, want [txt]", condition.Includes.Exts)
+	}
+	// 設定されていない項目は既定の条件を使う
+	if want := DefaultDocumentCondition().Excludes.DirNames; !slices.Equal(condition.Excludes.DirNames, want) {
+		t.Errorf("exclude dir names = %v, want %v", condition.Excludes.DirNames, want)
+	}
+
+	appConfig.ActiveWorkspaceName = "default"
+	if condition := DocumentConditionFor(appConfig); !slices.Equal(condition.Includes.Exts, []string{"md"}) {
+		t.Errorf("default include exts = %v, want [md]", condition.Includes.Exts)
+	}
+}
@@ -0,0 +1,358 @@
+package filesystem
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// メモリに読み込むアーカイブの最大サイズ
+	maxArchiveSize = 64 << 20
+	// 展開済みの内容を保持しておくアーカイブの数
+	maxCachedArchives = 8
+)
+
+var errArchiveTooLarge = errors.New("archive is too large")
+
+// archiveExts はディレクトリとして扱うアーカイブの拡張子
+var archiveExts = []string{".zip", ".tar", ".tar.gz", ".tgz"}
+
+func isArchiveName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// archive はbaseのzipやtarのファイルを読み取り専用のディレクトリとして扱うファイルシステム
+// アーカイブの中のアーカイブは展開しない
+type archive struct {
+	base FS
+
+	mu    sync.Mutex
+	cache map[string]*cachedArchive
+}
+
+type cachedArchive struct {
+	size     int64
+	modTime  time.Time
+	fsys     FS
+	lastUsed time.Time
+}
+
+// NewArchive はbaseを読み取り専用で公開し、アーカイブをディレクトリとして扱うファイルシステムを返す
+func NewArchive(base FS) *archive {
+	return &archive{
+		base:  base,
+		cache: map[string]*cachedArchive{},
+	}
+}
+
+func (a *archive) readOnly() {}
+
+// split はnameをアーカイブのパスとアーカイブ内のパスに分ける
+// アーカイブの中でない場合はarchivePathが空になる
+func (a *archive) split(name string) (archivePath string, inner string) {
+	name = filepath.Clean(name)
+	volume := filepath.VolumeName(name)
+	parts := strings.Split(strings.TrimPrefix(name[len(volume):], string(filepath.Separator)), string(filepath.Separator))
+
+	current := volume + string(filepath.Separator)
+	for i, part := range parts {
+		current = filepath.Join(current, part)
+		if !isArchiveName(part) {
+			continue
+		}
+		info, err := a.base.Stat(current)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		return current, filepath.Join(append([]string{string(filepath.Separator)}, parts[i+1:]...)...)
+	}
+	return "", ""
+}
+
+// open はアーカイブを展開した読み取り専用のファイルシステムを返す
+// アーカイブのファイルが変更されていなければ前回の結果を使う
+func (a *archive) open(archivePath string) (FS, fs.FileInfo, error) {
+	info, err := a.base.Stat(archivePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	a.mu.Lock()
+	cached, ok := a.cache[archivePath]
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		cached.lastUsed = time.Now()
+		a.mu.Unlock()
+		return cached.fsys, info, nil
+	}
+	a.mu.Unlock()
+
+	if info.Size() > maxArchiveSize {
+		return nil, nil, &fs.PathError{Op: "open", Path: archivePath, Err: errArchiveTooLarge}
+	}
+	data, err := a.base.ReadFile(archivePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	fsys, err := extract(archivePath, data)
+	if err != nil {
+		return nil, nil, &fs.PathError{Op: "open", Path: archivePath, Err: err}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.cache) >= maxCachedArchives {
+		a.evictLocked()
+	}
+	a.cache[archivePath] = &cachedArchive{
+		size:     info.Size(),
+		modTime:  info.ModTime(),
+		fsys:     fsys,
+		lastUsed: time.Now(),
+	}
+	return fsys, info, nil
+}
+
+// evictLocked は最も長く使われていないアーカイブを破棄する
+func (a *archive) evictLocked() {
+	var oldest string
+	for p, c := range a.cache {
+		if oldest == "" || c.lastUsed.Before(a.cache[oldest].lastUsed) {
+			oldest = p
+		}
+	}
+	delete(a.cache, oldest)
+}
+
+// extract はアーカイブの内容をルートディレクトリに配置したファイルシステムを返す
+func extract(archivePath string, data []byte) (FS, error) {
+	root := string(filepath.Separator)
+	lower := strings.ToLower(archivePath)
+	if strings.HasSuffix(lower, ".zip") {
+		r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		// 展開後のサイズも制限する
+		var total uint64
+		for _, f := range r.File {
+			if f.UncompressedSize64 > maxArchiveSize {
+				return nil, errArchiveTooLarge
+			}
+			total += f.UncompressedSize64
+			if total > maxArchiveSize {
+				return nil, errArchiveTooLarge
+			}
+		}
+		return NewReadOnly(root, zipFS{r}), nil
+	}
+
+	var r io.Reader = bytes.NewReader(data)
+	if strings.HasSuffix(lower, ".gz") || strings.HasSuffix(lower, ".tgz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return extractTar(r)
+}
+
+// zipFS はエントリを読み込む量を制限する
+// ヘッダーの展開後のサイズが実際と異なる場合にも上限を超えて読み込まない
+type zipFS struct {
+	*zip.Reader
+}
+
+func (z zipFS) ReadFile(name string) ([]byte, error) {
+	f, err := z.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxArchiveSize+1))
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	if len(data) > maxArchiveSize {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errArchiveTooLarge}
+	}
+	return data, nil
+}
+
+// extractTar はtarの通常のファイルとディレクトリをメモリ上に展開する
+// ルートの外を指すエントリやリンクは無視する
+func extractTar(r io.Reader) (FS, error) {
+	memory := NewMemory()
+	tr := tar.NewReader(r)
+	var total int64
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean("/" + header.Name)
+		if name == "/" || !fs.ValidPath(strings.TrimPrefix(name, "/")) {
+			continue
+		}
+		target := filepath.FromSlash(name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := memory.MkdirAll(target, 0755); err != nil {
+				return nil, err
+			}
+		case tar.TypeReg:
+			// 展開後のサイズも制限する
+			total += header.Size
+			if total > maxArchiveSize {
+				return nil, errArchiveTooLarge
+			}
+			content, err := io.ReadAll(io.LimitReader(tr, header.Size))
+			if err != nil {
+				return nil, err
+			}
+			if err := memory.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return nil, err
+			}
+			if err := memory.WriteFile(target, content, 0644); err != nil {
+				return nil, fmt.Errorf("%s: %w", header.Name, err)
+			}
+		}
+	}
+	return memory, nil
+}
+
+func (a *archive) Stat(name string) (fs.FileInfo, error) {
+	archivePath, inner := a.split(name)
+	if archivePath == "" {
+		info, err := a.base.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		if isArchive(info) {
+			return archiveDirInfo{info}, nil
+		}
+		return info, nil
+	}
+
+	fsys, archiveInfo, err := a.open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	if inner == string(filepath.Separator) {
+		return archiveDirInfo{archiveInfo}, nil
+	}
+	info, err := fsys.Stat(inner)
+	if err != nil {
+		return nil, pathError("stat", name, err)
+	}
+	return info, nil
+}
+
+func (a *archive) ReadDir(name string) ([]fs.DirEntry, error) {
+	archivePath, inner := a.split(name)
+	if archivePath == "" {
+		entries, err := a.base.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		for i, entry := range entries {
+			if entry.Type().IsRegular() && isArchiveName(entry.Name()) {
+				entries[i] = archiveDirEntry{entry}
+			}
+		}
+		return entries, nil
+	}
+
+	fsys, _, err := a.open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fsys.ReadDir(inner)
+	if err != nil {
+		return nil, pathError("readdir", name, err)
+	}
+	return entries, nil
+}
+
+func (a *archive) ReadFile(name string) ([]byte, error) {
+	archivePath, inner := a.split(name)
+	if archivePath == "" {
+		return a.base.ReadFile(name)
+	}
+	if inner == string(filepath.Separator) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errIsDir}
+	}
+
+	fsys, _, err := a.open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := fsys.ReadFile(inner)
+	if err != nil {
+		return nil, pathError("open", name, err)
+	}
+	return data, nil
+}
+
+func (a *archive) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+}
+
+func (a *archive) MkdirAll(path string, perm fs.FileMode) error {
+	return &fs.PathError{Op: "mkdir", Path: path, Err: fs.ErrPermission}
+}
+
+func (a *archive) Remove(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrPermission}
+}
+
+func isArchive(info fs.FileInfo) bool {
+	return info.Mode().IsRegular() && isArchiveName(info.Name())
+}
+
+// archiveDirInfo はアーカイブのファイルをディレクトリとして見せる
+type archiveDirInfo struct {
+	fs.FileInfo
+}
+
+func (i archiveDirInfo) Mode() fs.FileMode { return fs.ModeDir | 0555 }
+func (i archiveDirInfo) IsDir() bool       { return true }
+func (i archiveDirInfo) Size() int64       { return 0 }
+
+type archiveDirEntry struct {
+	fs.DirEntry
+}
+
+func (e archiveDirEntry) IsDir() bool       { return true }
+func (e archiveDirEntry) Type() fs.FileMode { return fs.ModeDir }
+func (e archiveDirEntry) Info() (fs.FileInfo, error) {
+	info, err := e.DirEntry.Info()
+	if err != nil {
+		return nil, err
+	}
+	return archiveDirInfo{info}, nil
+}
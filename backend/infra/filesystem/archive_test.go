@@ -0,0 +1,55 @@
+package filesystem
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractZip(t *testing.T) {
+	data := zipArchive(t, map[string]string{"docs/index.md": "# Index"})
+	fsys, err := extract("bundle.zip", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := fsys.ReadFile(filepath.FromSlash("/docs/index.md"))
+	if err != nil || string(content) != "# Index" {
+		t.Errorf("ReadFile = %q, %v", content, err)
+	}
+}
+
+func TestExtractZipRejectsLargeUncompressedSize(t *testing.T) {
+	// 圧縮すると小さくなるが展開すると上限を超える
+	data := zipArchive(t, map[string]string{
+		"a.md": strings.Repeat("a", maxArchiveSize/2+1),
+		"b.md": strings.Repeat("b", maxArchiveSize/2+1),
+	})
+	if len(data) > maxArchiveSize/100 {
+		t.Fatalf("test archive is not compressed: %d bytes", len(data))
+	}
+	if _, err := extract("bomb.zip", data); !errors.Is(err, errArchiveTooLarge) {
+		t.Errorf("extract = %v, want errArchiveTooLarge", err)
+	}
+}
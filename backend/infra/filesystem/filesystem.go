@@ -0,0 +1,65 @@
+package filesystem
+
+import (
+	"io/fs"
+	"os"
+)
+
+// FS はプロバイダーがドキュメントを読み書きするファイルシステム
+// パスはosパッケージと同じくOSの形式の絶対パスで表す
+type FS interface {
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	MkdirAll(path string, perm fs.FileMode) error
+	Remove(name string) error
+}
+
+// readOnlyFS は書き込みができないファイルシステム
+type readOnlyFS interface {
+	readOnly()
+}
+
+// IsReadOnly はfsysへの書き込みが常に失敗するかを返す
+func IsReadOnly(fsys FS) bool {
+	_, ok := fsys.(readOnlyFS)
+	return ok
+}
+
+type osFS struct{}
+
+// IsOS はfsysが実際のディスクのファイルシステムかを返す
+func IsOS(fsys FS) bool {
+	_, ok := fsys.(osFS)
+	return ok
+}
+
+// OS は実際のディスクを読み書きするファイルシステムを返す
+func OS() FS {
+	return osFS{}
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (osFS) MkdirAll(path string, perm fs.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (osFS) Remove(name string) error {
+	return os.Remove(name)
+}
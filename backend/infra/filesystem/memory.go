@@ -0,0 +1,218 @@
+package filesystem
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errIsDir    = errors.New("is a directory")
+	errNotDir   = errors.New("not a directory")
+	errNotEmpty = errors.New("directory not empty")
+)
+
+// memory はディスクに触れずにメモリ上でファイルを保持するファイルシステム
+// ルートディレクトリは常に存在する
+type memory struct {
+	mu    sync.RWMutex
+	nodes map[string]*memoryNode
+}
+
+type memoryNode struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (n *memoryNode) isDir() bool {
+	return n.mode.IsDir()
+}
+
+// NewMemory は空のメモリ上のファイルシステムを返す
+func NewMemory() *memory {
+	root := filepath.Clean(string(filepath.Separator))
+	return &memory{
+		nodes: map[string]*memoryNode{
+			root: {mode: fs.ModeDir | 0755, modTime: time.Now()},
+		},
+	}
+}
+
+// CopyFS はsrcの内容をdstのroot配下に書き込む
+// embed.FSに埋め込んだサンプルをメモリ上に展開する場合などに使う
+func CopyFS(dst FS, root string, src fs.FS) error {
+	return fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(root, filepath.FromSlash(name))
+		if d.IsDir() {
+			return dst.MkdirAll(target, 0755)
+		}
+		data, err := fs.ReadFile(src, name)
+		if err != nil {
+			return err
+		}
+		return dst.WriteFile(target, data, 0644)
+	})
+}
+
+func (m *memory) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key := filepath.Clean(name)
+	node, ok := m.nodes[key]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return node.info(key), nil
+}
+
+func (m *memory) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dir := filepath.Clean(name)
+	node, ok := m.nodes[dir]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	if !node.isDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+
+	var entries []fs.DirEntry
+	for key, child := range m.nodes {
+		if key != dir && filepath.Dir(key) == dir {
+			entries = append(entries, fs.FileInfoToDirEntry(child.info(key)))
+		}
+	}
+	// os.ReadDirと同じく名前順で返す
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+func (m *memory) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	node, ok := m.nodes[filepath.Clean(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if node.isDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errIsDir}
+	}
+	return slices.Clone(node.data), nil
+}
+
+func (m *memory) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := filepath.Clean(name)
+	parent, ok := m.nodes[filepath.Dir(key)]
+	if !ok {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if !parent.isDir() {
+		return &fs.PathError{Op: "open", Path: name, Err: errNotDir}
+	}
+	node, exists := m.nodes[key]
+	if exists && node.isDir() {
+		return &fs.PathError{Op: "open", Path: name, Err: errIsDir}
+	}
+	// ディスクと同じく、ファイルを作成した場合は親ディレクトリの更新日時も変わる
+	if !exists {
+		parent.modTime = time.Now()
+	}
+
+	m.nodes[key] = &memoryNode{
+		data:    slices.Clone(data),
+		mode:    perm.Perm(),
+		modTime: time.Now(),
+	}
+	return nil
+}
+
+func (m *memory) MkdirAll(path string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 存在しない親ディレクトリを上から順に作成する
+	var missing []string
+	for key := filepath.Clean(path); ; key = filepath.Dir(key) {
+		node, ok := m.nodes[key]
+		if ok {
+			if !node.isDir() {
+				return &fs.PathError{Op: "mkdir", Path: key, Err: errNotDir}
+			}
+			break
+		}
+		missing = append(missing, key)
+	}
+	for _, key := range slices.Backward(missing) {
+		if parent, ok := m.nodes[filepath.Dir(key)]; ok {
+			parent.modTime = time.Now()
+		}
+		m.nodes[key] = &memoryNode{mode: fs.ModeDir | perm.Perm(), modTime: time.Now()}
+	}
+	return nil
+}
+
+func (m *memory) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := filepath.Clean(name)
+	node, ok := m.nodes[key]
+	if !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if node.isDir() {
+		for other := range m.nodes {
+			if other != key && filepath.Dir(other) == key {
+				return &fs.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+			}
+		}
+		if filepath.Dir(key) == key {
+			return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrPermission}
+		}
+	}
+	delete(m.nodes, key)
+	if parent, ok := m.nodes[filepath.Dir(key)]; ok {
+		parent.modTime = time.Now()
+	}
+	return nil
+}
+
+func (n *memoryNode) info(key string) fs.FileInfo {
+	return memoryFileInfo{
+		name:    filepath.Base(key),
+		size:    int64(len(n.data)),
+		mode:    n.mode,
+		modTime: n.modTime,
+	}
+}
+
+type memoryFileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i memoryFileInfo) Name() string       { return i.name }
+func (i memoryFileInfo) Size() int64        { return i.size }
+func (i memoryFileInfo) Mode() fs.FileMode  { return i.mode }
+func (i memoryFileInfo) ModTime() time.Time { return i.modTime }
+func (i memoryFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i memoryFileInfo) Sys() any           { return nil }
@@ -0,0 +1,89 @@
+package filesystem
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// readOnly はfs.FS（embed.FSなど）をroot配下に読み取り専用で配置するファイルシステム
+type readOnly struct {
+	root string
+	fsys fs.FS
+}
+
+// NewReadOnly はfsysの内容をrootに配置した読み取り専用のファイルシステムを返す
+// 書き込み操作は全てfs.ErrPermissionを返す
+func NewReadOnly(root string, fsys fs.FS) *readOnly {
+	return &readOnly{root: filepath.Clean(root), fsys: fsys}
+}
+
+func (r *readOnly) readOnly() {}
+
+// fsPath はOSの形式のパスをfs.FSのパスに変換する
+// root配下でないパスは存在しないものとして扱う
+func (r *readOnly) fsPath(name string) (string, bool) {
+	rel, err := filepath.Rel(r.root, filepath.Clean(name))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// pathError はfs.FSのパスを含むエラーを呼び出し側が渡したパスに置き換える
+func pathError(op string, name string, err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return &fs.PathError{Op: op, Path: name, Err: pathErr.Err}
+	}
+	return err
+}
+
+func (r *readOnly) Stat(name string) (fs.FileInfo, error) {
+	p, ok := r.fsPath(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	info, err := fs.Stat(r.fsys, p)
+	if err != nil {
+		return nil, pathError("stat", name, err)
+	}
+	return info, nil
+}
+
+func (r *readOnly) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, ok := r.fsPath(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries, err := fs.ReadDir(r.fsys, p)
+	if err != nil {
+		return nil, pathError("readdir", name, err)
+	}
+	return entries, nil
+}
+
+func (r *readOnly) ReadFile(name string) ([]byte, error) {
+	p, ok := r.fsPath(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	data, err := fs.ReadFile(r.fsys, p)
+	if err != nil {
+		return nil, pathError("open", name, err)
+	}
+	return data, nil
+}
+
+func (r *readOnly) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+}
+
+func (r *readOnly) MkdirAll(path string, perm fs.FileMode) error {
+	return &fs.PathError{Op: "mkdir", Path: path, Err: fs.ErrPermission}
+}
+
+func (r *readOnly) Remove(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrPermission}
+}
@@ -0,0 +1,49 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrNotRepository はファイルがgitのリポジトリに含まれていないことを表す
+	ErrNotRepository = errors.New("not in a git repository")
+	// ErrRevisionNotFound はリビジョンが存在しないか、その時点にファイルが存在しないことを表す
+	ErrRevisionNotFound = errors.New("no such revision or path in the revision")
+	// ErrInvalidRevision はgitのオプションなど、リビジョンとして扱えない文字列を表す
+	ErrInvalidRevision = errors.New("invalid revision")
+)
+
+// Show はrevisionの時点のpathの内容を返す
+// pathを含むディレクトリでgitコマンドを実行するため、gitがインストールされている必要がある
+func Show(ctx context.Context, path string, revision string) (string, error) {
+	if revision == "" || strings.HasPrefix(revision, "-") || strings.ContainsAny(revision, ": \t\r\n") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidRevision, revision)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "-C", filepath.Dir(path), "show", revision+":./"+filepath.Base(path))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		message := strings.TrimSpace(stderr.String())
+		if strings.Contains(message, "not a git repository") {
+			return "", fmt.Errorf("%w: %s", ErrNotRepository, path)
+		}
+		return "", fmt.Errorf("%w: %s", ErrRevisionNotFound, message)
+	case errors.Is(err, exec.ErrNotFound):
+		return "", fmt.Errorf("%w: git is not installed", errors.ErrUnsupported)
+	case err != nil:
+		return "", err
+	}
+	return stdout.String(), nil
+}
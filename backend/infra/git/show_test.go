@@ -0,0 +1,70 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestShowRejectsInvalidRevisions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.md")
+	for _, revision := range []string{
+		"",
+		"-",
+		"--output=/tmp/x",
+		"-p",
+		"HEAD:other.md",
+		":doc.md",
+		"HEAD doc.md",
+		"HEAD\t",
+		"HEAD\n--all",
+		"HEAD\r",
+	} {
+		t.Run(revision, func(t *testing.T) {
+			if _, err := Show(context.Background(), path, revision); !errors.Is(err, ErrInvalidRevision) {
+				t.Errorf("Show(%q) error = %v, want %v", revision, err, ErrInvalidRevision)
+			}
+		})
+	}
+}
+
+func TestShow(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "doc.md")
+	if err := os.WriteFile(path, []byte("first\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	runGit("init", "-q")
+	runGit("add", "doc.md")
+	runGit("commit", "-q", "-m", "first")
+	if err := os.WriteFile(path, []byte("second\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit("commit", "-q", "-am", "second")
+
+	for revision, want := range map[string]string{"HEAD": "second\n", "HEAD~1": "first\n", "HEAD^": "first\n"} {
+		got, err := Show(context.Background(), path, revision)
+		if err != nil {
+			t.Fatalf("Show(%q): %v", revision, err)
+		}
+		if got != want {
+			t.Errorf("Show(%q) = %q, want %q", revision, got, want)
+		}
+	}
+	if _, err := Show(context.Background(), path, "HEAD~5"); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("Show(HEAD~5) error = %v, want %v", err, ErrRevisionNotFound)
+	}
+}
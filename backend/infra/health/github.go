@@ -0,0 +1,110 @@
+package health
+
+import (
+	"backend/config"
+	"backend/handler"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// GitHub APIの呼び出し回数を抑えるため、確認結果をこの間だけ再利用する
+const githubCheckTTL = 5 * time.Minute
+
+// GithubClient はGitHubのアクセストークンが有効かを確認するクライアント
+// テストやGitHub Enterpriseなどでは別の実装に差し替える
+type GithubClient interface {
+	ValidateToken(ctx context.Context, token string) error
+}
+
+type githubAPIClient struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+var _ GithubClient = (*githubAPIClient)(nil)
+
+// NewGithubClient はGitHub REST APIでトークンを確認するクライアントを返す
+func NewGithubClient(httpClient *http.Client, baseURL string) *githubAPIClient {
+	return &githubAPIClient{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+	}
+}
+
+func (c *githubAPIClient) ValidateToken(ctx context.Context, token string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/user", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return errors.New("access token is invalid or expired")
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("unexpected response from GitHub: %s", resp.Status)
+	}
+	return nil
+}
+
+type githubChecker struct {
+	client            GithubClient
+	appConfigProvider config.AppConfigProvider
+
+	mu        sync.Mutex
+	token     string
+	checkedAt time.Time
+	lastErr   error
+}
+
+var _ handler.HealthChecker = (*githubChecker)(nil)
+
+// NewGithubChecker は設定されたGitHubのアクセストークンが有効かを確認する
+func NewGithubChecker(client GithubClient, appConfigProvider config.AppConfigProvider) *githubChecker {
+	return &githubChecker{
+		client:            client,
+		appConfigProvider: appConfigProvider,
+	}
+}
+
+func (c *githubChecker) Name() string {
+	return "github"
+}
+
+func (c *githubChecker) CheckHealth(ctx context.Context) error {
+	appConfig, err := c.appConfigProvider.Load(ctx)
+	if err != nil {
+		return err
+	}
+	token := appConfig.Github.AccessToken.Reveal()
+	if token == "" {
+		return handler.ErrHealthCheckSkipped
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// トークンが変わった場合はすぐに確認し直す
+	if token == c.token && time.Since(c.checkedAt) < githubCheckTTL {
+		return c.lastErr
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c.token = token
+	c.checkedAt = time.Now()
+	c.lastErr = c.client.ValidateToken(ctx, token)
+	return c.lastErr
+}
@@ -3,23 +3,30 @@ package local
 import (
 	"backend/handler"
 	"context"
-	"os"
 )
 
 var _ handler.DirectoryProvider = (*local)(nil)
 
 func (p *local) GetDirectory(ctx context.Context, path string) ([]handler.FileInfo, error) {
-	entries, err := os.ReadDir(path)
+	entries, err := p.fsys.ReadDir(path)
 	if err != nil {
 		return nil, err
 	}
 
 	items := make([]handler.FileInfo, 0, len(entries))
 	for _, entry := range entries {
-		items = append(items, handler.FileInfo{
+		item := handler.FileInfo{
 			Name:  entry.Name(),
 			IsDir: entry.IsDir(),
-		})
+		}
+		// 一覧の取得後に削除された場合などは名前だけを返す
+		if info, err := entry.Info(); err == nil {
+			item.ModTime = info.ModTime()
+			if !item.IsDir {
+				item.Size = info.Size()
+			}
+		}
+		items = append(items, item)
 	}
 
 	return items, nil
@@ -3,13 +3,12 @@ package local
 import (
 	"backend/handler"
 	"context"
-	"os"
 )
 
 var _ handler.DocumentContentProvider = (*local)(nil)
 
 func (p *local) GetDocumentContent(ctx context.Context, path string) (string, error) {
-	content, err := os.ReadFile(path)
+	content, err := p.fsys.ReadFile(path)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,29 @@
+package local
+
+import (
+	"backend/handler"
+	"backend/infra/filesystem"
+	"backend/infra/git"
+	"context"
+	"errors"
+	"fmt"
+)
+
+var _ handler.DocumentRevisionProvider = (*local)(nil)
+
+// GetDocumentContentAt はgitのリビジョンの時点のドキュメントの内容を返す
+// ディスク上のファイルでのみ使え、メモリ上やアーカイブの中のファイルでは使えない
+func (p *local) GetDocumentContentAt(ctx context.Context, path string, revision string) (string, error) {
+	if !filesystem.IsOS(p.fsys) {
+		return "", errors.ErrUnsupported
+	}
+
+	content, err := git.Show(ctx, path, revision)
+	switch {
+	case errors.Is(err, git.ErrNotRepository), errors.Is(err, git.ErrRevisionNotFound), errors.Is(err, git.ErrInvalidRevision):
+		return "", fmt.Errorf("%w: %w", handler.ErrRevisionNotFound, err)
+	case err != nil:
+		return "", err
+	}
+	return content, nil
+}
@@ -3,35 +3,51 @@ package local
 import (
 	"backend/domain"
 	"backend/handler"
+	"backend/infra/filesystem"
+	"backend/metrics"
 	"context"
-	"os"
+	"io/fs"
 	"path/filepath"
-	"strings"
 	"sync"
+	"time"
 )
 
 var _ handler.DocumentsProvider = (*local)(nil)
 
 func (p *local) GetDocuments(ctx context.Context, path string, condition handler.DocumentCondition) ([]domain.Document, error) {
-	type fileInfo struct {
-		path string
-		info os.FileInfo
+	path = filepath.Clean(path)
+
+	// インデックス済みのディレクトリであれば走査せずに返す
+	// 最後の走査の後にディレクトリが変更されていれば、要求を待たせずにバックグラウンドで更新する
+	if idx := p.lookupIndex(path, condition); idx != nil {
+		docs := idx.documents(path)
+		idx.refreshIfStale()
+		return docs, nil
 	}
 
+	return walkDocuments(ctx, p.fsys, path, condition)
+}
+
+// walkDocuments はファイルシステムを走査してドキュメントを取得する
+func walkDocuments(ctx context.Context, fsys filesystem.FS, path string, condition handler.DocumentCondition) ([]domain.Document, error) {
 	// 適切なワーカー数（IOバウンドなので控えめに）
 	numWorkers := 8
-	fileChan := make(chan fileInfo, 1000)
+	fileChan := make(chan string, 1000)
 	resultChan := make(chan domain.Document, 100)
 
 	var wg sync.WaitGroup
 	var matchedFiles []domain.Document
 
+	// 走査の統計（走査goroutineのみが更新し、完了後に読む）
+	start := time.Now()
+	var stats metrics.WalkStats
+
 	// ワーカーgoroutineを起動（固定数でリソース使用を制御）
 	for range numWorkers {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for file := range fileChan {
+			for filePath := range fileChan {
 				select {
 				case <-ctx.Done():
 					return
@@ -39,11 +55,11 @@ func (p *local) GetDocuments(ctx context.Context, path string, condition handler
 				}
 
 				// 条件チェック（CPU処理）
-				if matchesCondition(file.path, file.info, condition) {
-					relPath, _ := filepath.Rel(path, file.path)
+				if matchesCondition(filePath, condition) {
+					relPath, _ := filepath.Rel(path, filePath)
 					select {
 					case resultChan <- domain.Document{
-						Path: file.path,
+						Path: filePath,
 						Name: relPath,
 					}:
 					case <-ctx.Done():
@@ -66,7 +82,7 @@ func (p *local) GetDocuments(ctx context.Context, path string, condition handler
 	// ファイルシステム走査（単一goroutineでIO最適化）
 	go func() {
 		defer close(fileChan)
-		filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+		filesystem.WalkDir(fsys, path, func(filePath string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
@@ -77,19 +93,19 @@ func (p *local) GetDocuments(ctx context.Context, path string, condition handler
 			default:
 			}
 
-			if info.IsDir() {
+			if d.IsDir() {
 				// ディレクトリ除外チェック
-				for _, dirName := range condition.Excludes.DirNames {
-					if dirName != "" && matchPattern(dirName, info.Name()) {
-						return filepath.SkipDir
-					}
+				if condition.ExcludesDir(d.Name()) {
+					stats.DirsSkipped++
+					return filepath.SkipDir
 				}
 				return nil
 			}
 
 			// ファイル情報をワーカーに送信
+			stats.FilesScanned++
 			select {
-			case fileChan <- fileInfo{path: filePath, info: info}:
+			case fileChan <- filePath:
 			case <-ctx.Done():
 				return ctx.Err()
 			}
@@ -106,6 +122,7 @@ func (p *local) GetDocuments(ctx context.Context, path string, condition handler
 
 	// 結果収集完了を待機
 	<-done
+	metrics.ObserveWalk("request", stats, time.Since(start))
 
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
@@ -119,59 +136,6 @@ func (p *local) GetDocuments(ctx context.Context, path string, condition handler
 }
 
 // ファイルが条件を満たすかチェック
-func matchesCondition(filePath string, info os.FileInfo, condition handler.DocumentCondition) bool {
-	fileName := info.Name()
-	dirName := filepath.Base(filepath.Dir(filePath))
-
-	// 除外条件をチェック
-	// 拡張子の除外チェック
-	for _, ext := range condition.Excludes.Exts {
-		if ext != "" && strings.HasSuffix(fileName, "."+ext) {
-			return false
-		}
-	}
-	// ディレクトリ名の除外チェック
-	for _, excludeDirName := range condition.Excludes.DirNames {
-		if excludeDirName != "" && matchPattern(excludeDirName, dirName) {
-			return false
-		}
-	}
-
-	// 含む条件をチェック
-	// 拡張子か拡張子リストが空の場合は全て対象
-	extMatches := len(condition.Includes.Exts) == 0
-	if !extMatches {
-		for _, ext := range condition.Includes.Exts {
-			if ext != "" && strings.HasSuffix(fileName, "."+ext) {
-				extMatches = true
-				break
-			}
-		}
-	}
-
-	// ディレクトリ名の条件チェック（空の場合は全て対象、*の場合も全て対象）
-	dirMatches := len(condition.Includes.DirNames) == 0
-	if !dirMatches {
-		for _, includeDirName := range condition.Includes.DirNames {
-			if includeDirName == "*" || matchPattern(includeDirName, dirName) {
-				dirMatches = true
-				break
-			}
-		}
-	}
-
-	return extMatches && dirMatches
-}
-
-// シンプルなパターンマッチング（*をワイルドカードとして使用）
-func matchPattern(pattern, str string) bool {
-	if pattern == "*" {
-		return true
-	}
-	if pattern == str {
-		return true
-	}
-	// より複雑なパターンマッチングが必要な場合は filepath.Match を使用
-	matched, _ := filepath.Match(pattern, str)
-	return matched
+func matchesCondition(filePath string, condition handler.DocumentCondition) bool {
+	return condition.MatchesFile(filepath.Base(filepath.Dir(filePath)), filepath.Base(filePath))
 }
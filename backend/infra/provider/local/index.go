@@ -0,0 +1,527 @@
+package local
+
+import (
+	"backend/domain"
+	"backend/handler"
+	"backend/infra/filesystem"
+	"backend/metrics"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ handler.IndexProgressReporter = (*local)(nil)
+
+// IndexEntry はインデックスに保存されるドキュメントのメタデータ
+type IndexEntry struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Hash    string    `json:"hash"`
+}
+
+// documentIndex は1つのディレクトリ配下のドキュメントを保持する
+type documentIndex struct {
+	fsys      filesystem.FS
+	root      string
+	storePath string
+	condition handler.DocumentCondition
+
+	// refreshMu は走査を同時に1つだけ実行するためのロック
+	refreshMu sync.Mutex
+
+	mu      sync.RWMutex
+	ready   bool
+	entries map[string]IndexEntry
+	// dirs は最後の走査で見つかったディレクトリの更新日時
+	dirs map[string]time.Time
+	// dirty は保存されていない変更があるかを表す
+	dirty bool
+	// updated は走査中にupdateで反映されたパス（走査中以外はnil）
+	updated map[string]struct{}
+	// refreshing は要求を契機としたバックグラウンドの確認と走査が実行中かを表す
+	refreshing bool
+	// checkedAt は要求を契機にディレクトリの変更を最後に確認した日時
+	checkedAt time.Time
+}
+
+// staleCheckInterval は要求を契機にディレクトリの変更を確認する最短の間隔
+const staleCheckInterval = 2 * time.Second
+
+type persistedIndex struct {
+	Root      string                    `json:"root"`
+	Condition handler.DocumentCondition `json:"condition"`
+	Entries   []IndexEntry              `json:"entries"`
+}
+
+func newDocumentIndex(fsys filesystem.FS, root string, storeDir string, condition handler.DocumentCondition) *documentIndex {
+	sum := sha256.Sum256([]byte(root))
+	return &documentIndex{
+		fsys:      fsys,
+		root:      root,
+		storePath: filepath.Join(storeDir, hex.EncodeToString(sum[:8])+".json"),
+		condition: condition,
+		entries:   map[string]IndexEntry{},
+	}
+}
+
+// load は保存済みのインデックスを読み込む
+// 再起動のたびに全体を走査し直さないよう、読み込んだ内容は最初の走査を待たずに使用する
+// ディレクトリの更新日時は保存しないため、最初の要求か定期的な走査で差分を更新する
+// 条件が変わっている場合は読み込まずに再構築させる
+func (idx *documentIndex) load() error {
+	b, err := os.ReadFile(idx.storePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var saved persistedIndex
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return err
+	}
+	if saved.Root != idx.root || !reflect.DeepEqual(saved.Condition, idx.condition) {
+		return nil
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, e := range saved.Entries {
+		idx.entries[e.Path] = e
+	}
+	idx.ready = true
+	return nil
+}
+
+func (idx *documentIndex) save() (err error) {
+	idx.mu.Lock()
+	saved := persistedIndex{
+		Root:      idx.root,
+		Condition: idx.condition,
+		Entries:   idx.sortedEntries(),
+	}
+	idx.dirty = false
+	idx.mu.Unlock()
+
+	// 保存に失敗した場合は次回に再度保存する
+	defer func() {
+		if err != nil {
+			idx.mu.Lock()
+			idx.dirty = true
+			idx.mu.Unlock()
+		}
+	}()
+
+	b, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(idx.storePath), fs.ModePerm); err != nil {
+		return err
+	}
+
+	// 書き込み途中のファイルを読まないように一時ファイルからリネームする
+	tmp := idx.storePath + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, idx.storePath)
+}
+
+// refresh はファイルシステムを走査してインデックスを差分更新する
+// サイズと更新日時が変わっていないファイルはハッシュを再計算しない
+// 走査中にupdateで反映された変更は走査の結果より優先する
+func (idx *documentIndex) refresh(ctx context.Context) (bool, error) {
+	idx.refreshMu.Lock()
+	defer idx.refreshMu.Unlock()
+
+	idx.mu.Lock()
+	prev := make(map[string]IndexEntry, len(idx.entries))
+	for k, v := range idx.entries {
+		prev[k] = v
+	}
+	idx.updated = map[string]struct{}{}
+	idx.mu.Unlock()
+
+	next := make(map[string]IndexEntry, len(prev))
+	dirs := map[string]time.Time{}
+	changed := false
+
+	start := time.Now()
+	var stats metrics.WalkStats
+
+	err := filesystem.WalkDir(idx.fsys, idx.root, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 読めないディレクトリは無視して走査を続ける
+			if d != nil && d.IsDir() && filePath != idx.root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if d.IsDir() {
+			if filePath != idx.root && idx.condition.ExcludesDir(d.Name()) {
+				stats.DirsSkipped++
+				return filepath.SkipDir
+			}
+			if info, err := d.Info(); err == nil {
+				dirs[filePath] = info.ModTime()
+			}
+			return nil
+		}
+		stats.FilesScanned++
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if !matchesCondition(filePath, idx.condition) {
+			return nil
+		}
+
+		if old, ok := prev[filePath]; ok && old.Size == info.Size() && old.ModTime.Equal(info.ModTime()) {
+			next[filePath] = old
+			return nil
+		}
+
+		entry, err := newIndexEntry(idx.fsys, filePath, info)
+		if err != nil {
+			return nil
+		}
+		next[filePath] = entry
+		changed = true
+		return nil
+	})
+	if err != nil {
+		idx.mu.Lock()
+		idx.updated = nil
+		idx.mu.Unlock()
+		return false, err
+	}
+	metrics.ObserveWalk("index", stats, time.Since(start))
+
+	if len(next) != len(prev) {
+		changed = true
+	}
+
+	idx.mu.Lock()
+	// 走査中の書き込み操作の結果を失わないよう、現在の内容で上書きする
+	for path := range idx.updated {
+		if e, ok := idx.entries[path]; ok {
+			next[path] = e
+		} else {
+			delete(next, path)
+		}
+	}
+	idx.updated = nil
+	idx.entries = next
+	idx.dirs = dirs
+	idx.ready = true
+	idx.dirty = idx.dirty || changed
+	idx.mu.Unlock()
+
+	return changed, nil
+}
+
+// update は単一ファイルの変更をインデックスに反映する
+func (idx *documentIndex) update(filePath string) {
+	if !idx.contains(filePath) {
+		return
+	}
+
+	info, err := idx.fsys.Stat(filePath)
+	if err != nil {
+		idx.mu.Lock()
+		delete(idx.entries, filePath)
+		idx.markUpdatedLocked(filePath)
+		idx.dirty = true
+		idx.mu.Unlock()
+		return
+	}
+	if info.IsDir() || !matchesCondition(filePath, idx.condition) || idx.inExcludedDir(filePath) {
+		return
+	}
+
+	entry, err := newIndexEntry(idx.fsys, filePath, info)
+	if err != nil {
+		return
+	}
+	idx.mu.Lock()
+	idx.entries[filePath] = entry
+	idx.markUpdatedLocked(filePath)
+	idx.dirty = true
+	idx.mu.Unlock()
+}
+
+// markUpdatedLocked は走査中であれば変更されたパスを記録する
+func (idx *documentIndex) markUpdatedLocked(filePath string) {
+	if idx.updated != nil {
+		idx.updated[filePath] = struct{}{}
+	}
+}
+
+// refreshIfStale はディレクトリが最後の走査の後に変更されていればバックグラウンドで走査する
+// 要求を待たせず、要求ごとに全てのディレクトリをstatしないよう、確認も間隔を空けてバックグラウンドで行う
+func (idx *documentIndex) refreshIfStale() {
+	idx.mu.Lock()
+	if idx.refreshing || time.Since(idx.checkedAt) < staleCheckInterval {
+		idx.mu.Unlock()
+		return
+	}
+	idx.refreshing = true
+	idx.checkedAt = time.Now()
+	idx.mu.Unlock()
+
+	go func() {
+		defer func() {
+			idx.mu.Lock()
+			idx.refreshing = false
+			idx.mu.Unlock()
+		}()
+		if !idx.stale(idx.root) {
+			return
+		}
+		if _, err := idx.refresh(context.Background()); err != nil {
+			log.Printf("Failed to refresh index for %s: %v", idx.root, err)
+		}
+	}()
+}
+
+// stale はpath配下のディレクトリが最後の走査の後に変更されたかを返す
+// ファイルの追加、削除、名前の変更はディレクトリの更新日時で検出できる
+// 保存済みのインデックスを読み込んだだけで走査していない場合は常に変更ありとする
+func (idx *documentIndex) stale(path string) bool {
+	idx.mu.RLock()
+	if idx.dirs == nil {
+		idx.mu.RUnlock()
+		return true
+	}
+	dirs := make(map[string]time.Time, len(idx.dirs))
+	for dir, modTime := range idx.dirs {
+		if dir == path || strings.HasPrefix(dir, withSeparator(path)) {
+			dirs[dir] = modTime
+		}
+	}
+	idx.mu.RUnlock()
+
+	for dir, modTime := range dirs {
+		info, err := idx.fsys.Stat(dir)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains はpathがインデックスの対象範囲に含まれるかを返す
+func (idx *documentIndex) contains(path string) bool {
+	return path == idx.root || strings.HasPrefix(path, withSeparator(idx.root))
+}
+
+func withSeparator(dir string) string {
+	if strings.HasSuffix(dir, string(filepath.Separator)) {
+		return dir
+	}
+	return dir + string(filepath.Separator)
+}
+
+func (idx *documentIndex) inExcludedDir(filePath string) bool {
+	rel, err := filepath.Rel(idx.root, filepath.Dir(filePath))
+	if err != nil || rel == "." {
+		return false
+	}
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		if idx.condition.ExcludesDir(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// documents はpath配下のドキュメントをインデックスから返す
+func (idx *documentIndex) documents(path string) []domain.Document {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	prefix := withSeparator(path)
+	docs := []domain.Document{}
+	for _, e := range idx.sortedEntries() {
+		if !strings.HasPrefix(e.Path, prefix) {
+			continue
+		}
+		relPath, _ := filepath.Rel(path, e.Path)
+		docs = append(docs, domain.Document{
+			Path: e.Path,
+			Name: relPath,
+		})
+	}
+	return docs
+}
+
+// sortedEntries は呼び出し側でロックを取得している前提
+func (idx *documentIndex) sortedEntries() []IndexEntry {
+	entries := make([]IndexEntry, 0, len(idx.entries))
+	for _, e := range idx.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries
+}
+
+func newIndexEntry(fsys filesystem.FS, filePath string, info fs.FileInfo) (IndexEntry, error) {
+	content, err := fsys.ReadFile(filePath)
+	if err != nil {
+		return IndexEntry{}, err
+	}
+
+	sum := sha256.Sum256(content)
+	return IndexEntry{
+		Path:    filePath,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Hash:    hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// EnableIndex はdirs配下のドキュメントをインデックス化する
+// 保存済みのインデックスがあれば読み込み、起動直後から利用できるようにする
+func (p *local) EnableIndex(storeDir string, dirs []string, condition handler.DocumentCondition) {
+	p.indexMu.Lock()
+	defer p.indexMu.Unlock()
+
+	indexes := make(map[string]*documentIndex, len(dirs))
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		// 条件が変わった場合は作り直す（lookupIndexで使われなくなるため）
+		if idx, ok := p.indexes[dir]; ok && reflect.DeepEqual(idx.condition, condition) {
+			indexes[dir] = idx
+			continue
+		}
+		idx := newDocumentIndex(p.fsys, dir, storeDir, condition)
+		if err := idx.load(); err != nil {
+			log.Printf("Failed to load index for %s: %v", dir, err)
+		}
+		indexes[dir] = idx
+	}
+	p.indexes = indexes
+}
+
+// RunIndexer はintervalごとにインデックスを差分更新する
+// ctxがキャンセルされるまでブロックする
+func (p *local) RunIndexer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.RefreshIndexes(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshIndexes は全てのインデックスを更新し、変更があれば保存する
+func (p *local) RefreshIndexes(ctx context.Context) {
+	for _, idx := range p.indexList() {
+		if _, err := idx.refresh(ctx); err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to refresh index for %s: %v", idx.root, err)
+			}
+			continue
+		}
+	}
+	if err := p.FlushIndexes(); err != nil {
+		log.Printf("Failed to save index: %v", err)
+	}
+}
+
+// FlushIndexes は保存されていない変更があるインデックスを全て保存する
+// 終了時に書き込み操作の結果を失わないように呼び出す
+func (p *local) FlushIndexes() error {
+	var errs []error
+	for _, idx := range p.indexList() {
+		idx.mu.RLock()
+		dirty := idx.dirty
+		idx.mu.RUnlock()
+		if !dirty {
+			continue
+		}
+		if err := idx.save(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", idx.root, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *local) indexList() []*documentIndex {
+	p.indexMu.RLock()
+	defer p.indexMu.RUnlock()
+
+	list := make([]*documentIndex, 0, len(p.indexes))
+	for _, idx := range p.indexes {
+		list = append(list, idx)
+	}
+	return list
+}
+
+// lookupIndex はpathを含み、conditionが一致する構築済みインデックスを返す
+func (p *local) lookupIndex(path string, condition handler.DocumentCondition) *documentIndex {
+	for _, idx := range p.indexList() {
+		if !idx.contains(path) || !reflect.DeepEqual(idx.condition, condition) {
+			continue
+		}
+		idx.mu.RLock()
+		ready := idx.ready
+		idx.mu.RUnlock()
+		if ready {
+			return idx
+		}
+	}
+	return nil
+}
+
+// notifyChanged は書き込み操作の結果をインデックスに反映する
+func (p *local) notifyChanged(path string) {
+	for _, idx := range p.indexList() {
+		idx.update(path)
+	}
+}
+
+// IndexProgress は構築済みのインデックスの数を返す
+func (p *local) IndexProgress() handler.IndexProgress {
+	progress := handler.IndexProgress{}
+	for _, idx := range p.indexList() {
+		idx.mu.RLock()
+		if idx.ready {
+			progress.Ready++
+		}
+		idx.mu.RUnlock()
+		progress.Total++
+	}
+	return progress
+}
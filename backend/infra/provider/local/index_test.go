@@ -0,0 +1,238 @@
+package local
+
+import (
+	"backend/handler"
+	"backend/infra/filesystem"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func documentPaths(t *testing.T, p *local, dir string, condition handler.DocumentCondition) []string {
+	t.Helper()
+	docs, err := p.GetDocuments(context.Background(), dir, condition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, d := range docs {
+		paths = append(paths, d.Path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// blockingFS は最初のReadDirを止め、走査中に書き込み操作を行えるようにする
+type blockingFS struct {
+	filesystem.FS
+	root    string
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := b.FS.ReadDir(name)
+	if name == b.root {
+		b.once.Do(func() {
+			close(b.started)
+			<-b.release
+		})
+	}
+	return entries, err
+}
+
+func TestRefreshKeepsUpdatesDuringWalk(t *testing.T) {
+	dir := t.TempDir()
+	kept, removed := filepath.Join(dir, "kept.md"), filepath.Join(dir, "removed.md")
+	writeFile(t, kept, "a")
+	writeFile(t, removed, "b")
+
+	fsys := &blockingFS{FS: filesystem.OS(), root: dir, started: make(chan struct{}), release: make(chan struct{})}
+	p, _ := NewLocalProvider(fsys)
+	condition := handler.DefaultDocumentCondition()
+	p.EnableIndex(t.TempDir(), []string{dir}, condition)
+	idx := p.indexList()[0]
+
+	done := make(chan error)
+	go func() {
+		_, err := idx.refresh(context.Background())
+		done <- err
+	}()
+
+	// 走査がディレクトリを読んだ後に作成と削除を行う
+	<-fsys.started
+	created := filepath.Join(dir, "created.md")
+	if err := p.CreateDocument(context.Background(), created); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.DeleteDocument(context.Background(), removed); err != nil {
+		t.Fatal(err)
+	}
+	close(fsys.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	idx.mu.RLock()
+	_, hasCreated := idx.entries[created]
+	_, hasRemoved := idx.entries[removed]
+	_, hasKept := idx.entries[kept]
+	idx.mu.RUnlock()
+	if !hasCreated || hasRemoved || !hasKept {
+		t.Errorf("entries after refresh: created=%v removed=%v kept=%v", hasCreated, hasRemoved, hasKept)
+	}
+}
+
+func TestEnableIndexRebuildsWhenConditionChanges(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.md"), "a")
+	writeFile(t, filepath.Join(dir, "b.txt"), "b")
+
+	p, _ := NewLocalProvider(filesystem.OS())
+	storeDir := t.TempDir()
+	p.EnableIndex(storeDir, []string{dir}, handler.DefaultDocumentCondition())
+	p.RefreshIndexes(context.Background())
+
+	condition := handler.DefaultDocumentCondition()
+	condition.Includes.Exts = []string{"txt"}
+	p.EnableIndex(storeDir, []string{dir}, condition)
+	p.RefreshIndexes(context.Background())
+
+	if p.lookupIndex(dir, condition) == nil {
+		t.Fatal("index for the new condition is not used")
+	}
+	if got, want := documentPaths(t, p, dir, condition), []string{filepath.Join(dir, "b.txt")}; !slices.Equal(got, want) {
+		t.Errorf("documents = %v, want %v", got, want)
+	}
+}
+
+// waitForDocuments はバックグラウンドの更新でpath配下のドキュメントがwantになるまで待つ
+func waitForDocuments(t *testing.T, p *local, dir string, condition handler.DocumentCondition, want []string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got := documentPaths(t, p, dir, condition)
+		if slices.Equal(got, want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("documents = %v, want %v", got, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPersistedIndexIsReadyAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.md")
+	writeFile(t, a, "a")
+	storeDir := t.TempDir()
+	condition := handler.DefaultDocumentCondition()
+
+	p, _ := NewLocalProvider(filesystem.OS())
+	p.EnableIndex(storeDir, []string{dir}, condition)
+	p.RefreshIndexes(context.Background())
+
+	// 停止中に追加されたファイル
+	b := filepath.Join(dir, "b.md")
+	writeFile(t, b, "b")
+
+	// 保存済みのインデックスを読み込んだ時点で使い、全体の走査を待たない
+	reloaded, _ := NewLocalProvider(filesystem.OS())
+	reloaded.EnableIndex(storeDir, []string{dir}, condition)
+	if reloaded.lookupIndex(dir, condition) == nil {
+		t.Fatal("persisted index is not used")
+	}
+	if got := reloaded.IndexProgress().Ready; got != 1 {
+		t.Errorf("ready = %d, want 1", got)
+	}
+	if got, want := documentPaths(t, reloaded, dir, condition), []string{a}; !slices.Equal(got, want) {
+		t.Errorf("documents = %v, want %v", got, want)
+	}
+
+	// 最初の要求を契機にバックグラウンドで差分を更新する
+	waitForDocuments(t, reloaded, dir, condition, []string{a, b})
+}
+
+func TestGetDocumentsSeesFilesAddedAfterRefresh(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.md"), "a")
+	condition := handler.DefaultDocumentCondition()
+
+	p, _ := NewLocalProvider(filesystem.OS())
+	p.EnableIndex(t.TempDir(), []string{dir}, condition)
+	p.RefreshIndexes(context.Background())
+
+	// プロバイダーを通さずに追加する（エディターやgitによる変更）
+	sub := filepath.Join(dir, "sub")
+	writeFile(t, filepath.Join(sub, "b.md"), "b")
+	// 更新日時の精度が粗いファイルシステムでも変更を検出できるようにする
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(dir, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	// 要求では現在のインデックスを返し、走査はバックグラウンドで行う
+	if got, want := documentPaths(t, p, dir, condition), []string{filepath.Join(dir, "a.md")}; !slices.Equal(got, want) {
+		t.Errorf("documents = %v, want %v", got, want)
+	}
+	waitForDocuments(t, p, dir, condition, []string{filepath.Join(dir, "a.md"), filepath.Join(sub, "b.md")})
+}
+
+func TestRefreshIfStaleChecksAtMostOncePerInterval(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.md"), "a")
+	condition := handler.DefaultDocumentCondition()
+
+	fsys := &countingFS{FS: filesystem.OS()}
+	p, _ := NewLocalProvider(fsys)
+	p.EnableIndex(t.TempDir(), []string{dir}, condition)
+	p.RefreshIndexes(context.Background())
+	idx := p.indexList()[0]
+	fsys.stats.Store(0)
+
+	for range 10 {
+		documentPaths(t, p, dir, condition)
+	}
+	// バックグラウンドの確認が終わるのを待つ
+	for {
+		idx.mu.RLock()
+		refreshing := idx.refreshing
+		idx.mu.RUnlock()
+		if !refreshing {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := fsys.stats.Load(); got != 1 {
+		t.Errorf("stat calls = %d, want 1", got)
+	}
+}
+
+// countingFS はStatの呼び出し回数を数える
+type countingFS struct {
+	filesystem.FS
+	stats atomic.Int64
+}
+
+func (c *countingFS) Stat(name string) (fs.FileInfo, error) {
+	c.stats.Add(1)
+	return c.FS.Stat(name)
+}
@@ -1,30 +1,67 @@
 package local
 
 import (
-	"backend/domain"
 	"backend/handler"
+	"backend/infra/filesystem"
 	"context"
-	"os"
+	"path/filepath"
+	"sync"
 )
 
 type local struct {
-}
+	fsys filesystem.FS
 
-func NewLocalProvider() (*local, error) {
-	return &local{}, nil
+	indexMu sync.RWMutex
+	indexes map[string]*documentIndex
 }
 
-func (p *local) Match(kind domain.RepoKind) bool {
-	return kind == domain.LocalRepoKind
+// NewLocalProvider はfsysのディレクトリを取得元とするプロバイダーを返す
+// 通常はfilesystem.OS()を渡し、デモではメモリ上のファイルシステムを渡す
+func NewLocalProvider(fsys filesystem.FS) (*local, error) {
+	return &local{
+		fsys:    fsys,
+		indexes: map[string]*documentIndex{},
+	}, nil
 }
 
 var _ handler.DocumentCreateProvider = (*local)(nil)
 var _ handler.DocumentDeleteProvider = (*local)(nil)
+var _ handler.CapabilityReporter = (*local)(nil)
+
+// Capabilities は読み取り専用のファイルシステムでは書き込み操作を提供しない
+func (p *local) Capabilities() []handler.Capability {
+	if filesystem.IsReadOnly(p.fsys) {
+		return []handler.Capability{handler.CapabilityList, handler.CapabilityBrowse, handler.CapabilityRead}
+	}
+	return []handler.Capability{
+		handler.CapabilityList,
+		handler.CapabilityBrowse,
+		handler.CapabilityRead,
+		handler.CapabilityWrite,
+		handler.CapabilityCreate,
+		handler.CapabilityDelete,
+	}
+}
 
+// CreateDocument は親ディレクトリを作成し、同じパスのファイルが存在しない場合のみ空のドキュメントを作成する
 func (p *local) CreateDocument(ctx context.Context, path string) error {
-	return os.WriteFile(path, []byte(""), 0644)
+	if _, err := p.fsys.Stat(path); err == nil {
+		return handler.ErrDocumentExists
+	}
+	if err := p.fsys.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := p.fsys.WriteFile(path, []byte(""), 0644); err != nil {
+		return err
+	}
+	p.notifyChanged(path)
+	return nil
 }
 
 func (p *local) DeleteDocument(ctx context.Context, path string) error {
-	return os.Remove(path)
+	if err := p.fsys.Remove(path); err != nil {
+		return err
+	}
+	p.notifyChanged(path)
+	return nil
 }
@@ -0,0 +1,217 @@
+package plugin
+
+import (
+	"backend/config"
+	"backend/domain"
+	"backend/handler"
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"time"
+)
+
+// プラグインとのプロトコルのバージョン
+const protocolVersion = 1
+
+// プラグインが実装するメソッド
+// initializeの結果でkindと実装しているcapabilitiesを通知する
+// capabilitiesはhandler.Capabilityの値（list, browse, read, write, create, delete）
+const (
+	methodInitialize     = "initialize"      // {protocol_version} -> {kind, capabilities}
+	methodShutdown       = "shutdown"        // 通知
+	methodGetDocuments   = "documents/list"  // {path, condition} -> {documents}
+	methodGetDirectory   = "directory/list"  // {path} -> {items}
+	methodGetContent     = "document/get"    // {path} -> {content}
+	methodUpdateContent  = "document/update" // {path, content} -> {}
+	methodCreateDocument = "document/create" // {path} -> {}
+	methodDeleteDocument = "document/delete" // {path} -> {}
+)
+
+const (
+	// 起動してからinitializeに応答するまでの待ち時間
+	initializeTimeout = 10 * time.Second
+	// 終了を通知してからプロセスが終了するまでの待ち時間
+	shutdownTimeout = 5 * time.Second
+)
+
+type plugin struct {
+	name         string
+	kind         domain.RepoKind
+	capabilities []handler.Capability
+
+	cmd    *exec.Cmd
+	client *rpcClient
+	done   chan struct{}
+	// waitErr はdoneが閉じられた後にのみ参照する
+	waitErr error
+}
+
+var _ handler.DocumentsProvider = (*plugin)(nil)
+var _ handler.DirectoryProvider = (*plugin)(nil)
+var _ handler.DocumentContentProvider = (*plugin)(nil)
+var _ handler.DocumentContentUpdateProvider = (*plugin)(nil)
+var _ handler.DocumentCreateProvider = (*plugin)(nil)
+var _ handler.DocumentDeleteProvider = (*plugin)(nil)
+var _ handler.CapabilityReporter = (*plugin)(nil)
+var _ handler.HealthChecker = (*plugin)(nil)
+
+type initializeResult struct {
+	Kind         string               `json:"kind"`
+	Capabilities []handler.Capability `json:"capabilities"`
+}
+
+// Start はプラグインを起動し、プラグインが通知したkindを登録する
+// プラグインの標準エラー出力はloggerに出力する
+func Start(ctx context.Context, cfg config.Plugin, logger *slog.Logger) (*plugin, error) {
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Env = os.Environ()
+	for k, v := range cfg.Env {
+		cmd.Env = append(cmd.Env, k+"="+v.Reveal())
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", cfg.Name, err)
+	}
+
+	p := &plugin{
+		name:   cfg.Name,
+		cmd:    cmd,
+		client: newRPCClient(stdout, stdin),
+		done:   make(chan struct{}),
+	}
+
+	logger = logger.With(slog.String("plugin", cfg.Name))
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			logger.Info(scanner.Text())
+		}
+	}()
+	go func() {
+		// Waitはパイプを閉じるため、標準出力と標準エラー出力を最後まで読んでから呼ぶ
+		<-p.client.readDone
+		<-stderrDone
+		p.waitErr = cmd.Wait()
+		close(p.done)
+	}()
+
+	initCtx, cancel := context.WithTimeout(ctx, initializeTimeout)
+	defer cancel()
+
+	var result initializeResult
+	if err := p.client.call(initCtx, methodInitialize, map[string]any{"protocol_version": protocolVersion}, &result); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("failed to initialize plugin %s: %w", cfg.Name, err)
+	}
+
+	kind, err := domain.RegisterRepoKind(result.Kind)
+	if err != nil {
+		p.Close()
+		return nil, fmt.Errorf("plugin %s: %w", cfg.Name, err)
+	}
+	p.kind = kind
+	p.capabilities = result.Capabilities
+
+	logger.Info("plugin started", slog.String("kind", kind.String()), slog.Any("capabilities", result.Capabilities))
+	return p, nil
+}
+
+// Kind はプラグインが通知したkindを返す
+func (p *plugin) Kind() domain.RepoKind {
+	return p.kind
+}
+
+func (p *plugin) Capabilities() []handler.Capability {
+	return slices.Clone(p.capabilities)
+}
+
+func (p *plugin) Name() string {
+	return "plugin:" + p.name
+}
+
+// CheckHealth はプラグインのプロセスが終了していないかを確認する
+func (p *plugin) CheckHealth(ctx context.Context) error {
+	select {
+	case <-p.done:
+		return fmt.Errorf("plugin exited: %v", p.waitErr)
+	default:
+		return nil
+	}
+}
+
+// Close はプラグインに終了を通知し、終了しない場合は強制終了する
+func (p *plugin) Close() error {
+	select {
+	case <-p.done:
+		return nil
+	default:
+	}
+
+	_ = p.client.notify(methodShutdown, nil)
+	_ = p.client.close()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-time.After(shutdownTimeout):
+	}
+	if err := p.cmd.Process.Kill(); err != nil {
+		return err
+	}
+	<-p.done
+	return nil
+}
+
+func (p *plugin) GetDocuments(ctx context.Context, path string, condition handler.DocumentCondition) ([]domain.Document, error) {
+	var result struct {
+		Documents []domain.Document `json:"documents"`
+	}
+	err := p.client.call(ctx, methodGetDocuments, map[string]any{"path": path, "condition": condition}, &result)
+	return result.Documents, err
+}
+
+func (p *plugin) GetDirectory(ctx context.Context, path string) ([]handler.FileInfo, error) {
+	var result struct {
+		Items []handler.FileInfo `json:"items"`
+	}
+	err := p.client.call(ctx, methodGetDirectory, map[string]any{"path": path}, &result)
+	return result.Items, err
+}
+
+func (p *plugin) GetDocumentContent(ctx context.Context, path string) (string, error) {
+	var result struct {
+		Content string `json:"content"`
+	}
+	err := p.client.call(ctx, methodGetContent, map[string]any{"path": path}, &result)
+	return result.Content, err
+}
+
+func (p *plugin) UpdateDocumentContent(ctx context.Context, path string, content string) error {
+	return p.client.call(ctx, methodUpdateContent, map[string]any{"path": path, "content": content}, nil)
+}
+
+func (p *plugin) CreateDocument(ctx context.Context, path string) error {
+	return p.client.call(ctx, methodCreateDocument, map[string]any{"path": path}, nil)
+}
+
+func (p *plugin) DeleteDocument(ctx context.Context, path string) error {
+	return p.client.call(ctx, methodDeleteDocument, map[string]any{"path": path}, nil)
+}
@@ -0,0 +1,169 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// JSON-RPC 2.0のメッセージを1行に1つずつ標準入出力でやり取りする
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      *int64 `json:"id,omitempty"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *RPCError       `json:"error"`
+}
+
+// RPCError はプラグインから返されたエラー
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// errClosed はプラグインとの接続が切れたことを表す
+var errClosed = errors.New("plugin connection closed")
+
+type rpcClient struct {
+	writeMu sync.Mutex
+	w       io.WriteCloser
+	encoder *json.Encoder
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan rpcResponse
+	err     error
+
+	// readDone はreadLoopが終了すると閉じられる
+	readDone chan struct{}
+}
+
+func newRPCClient(r io.Reader, w io.WriteCloser) *rpcClient {
+	c := &rpcClient{
+		w:        w,
+		encoder:  json.NewEncoder(w),
+		pending:  map[int64]chan rpcResponse{},
+		readDone: make(chan struct{}),
+	}
+	go c.readLoop(r)
+	return c
+}
+
+// call はmethodを呼び出し、結果をresultに格納する
+func (c *rpcClient) call(ctx context.Context, method string, params any, result any) error {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan rpcResponse, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(rpcRequest{JSONRPC: "2.0", ID: &id, Method: method, Params: params}); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case resp, ok := <-ch:
+		if !ok {
+			return c.closeErr()
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	}
+}
+
+// notify は応答を必要としない通知を送る
+func (c *rpcClient) notify(method string, params any) error {
+	return c.write(rpcRequest{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (c *rpcClient) write(req rpcRequest) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	// Encoderは末尾に改行を付ける
+	return c.encoder.Encode(req)
+}
+
+func (c *rpcClient) readLoop(r io.Reader) {
+	defer close(c.readDone)
+	decoder := json.NewDecoder(r)
+	for {
+		var resp rpcResponse
+		if err := decoder.Decode(&resp); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errClosed
+			}
+			c.closeWithError(err)
+			return
+		}
+		// 通知やIDの無い応答は無視する
+		if resp.ID == nil {
+			continue
+		}
+
+		c.mu.Lock()
+		ch, ok := c.pending[*resp.ID]
+		c.mu.Unlock()
+		// 同じIDの応答が複数返されても読み込みを止めない
+		if ok {
+			select {
+			case ch <- resp:
+			default:
+			}
+		}
+	}
+}
+
+func (c *rpcClient) closeWithError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
+
+func (c *rpcClient) closeErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *rpcClient) close() error {
+	return c.w.Close()
+}
@@ -0,0 +1,56 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+)
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestCallIgnoresDuplicateResponses(t *testing.T) {
+	requestReader, requestWriter := io.Pipe()
+	responseReader, responseWriter := io.Pipe()
+	client := newRPCClient(responseReader, nopWriteCloser{requestWriter})
+
+	// 全ての要求に同じ応答を2回返す
+	go func() {
+		scanner := bufio.NewScanner(requestReader)
+		encoder := json.NewEncoder(responseWriter)
+		for scanner.Scan() {
+			var req rpcRequest
+			if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+				return
+			}
+			resp := rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`"ok"`)}
+			_ = encoder.Encode(resp)
+			_ = encoder.Encode(resp)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for range 3 {
+		var result string
+		if err := client.call(ctx, "ping", nil, &result); err != nil {
+			t.Fatal(err)
+		}
+		if result != "ok" {
+			t.Errorf("result = %q, want ok", result)
+		}
+	}
+
+	responseWriter.Close()
+	select {
+	case <-client.readDone:
+	case <-ctx.Done():
+		t.Fatal("readLoop did not finish after the connection was closed")
+	}
+}
@@ -0,0 +1,43 @@
+package s3
+
+import (
+	"backend/handler"
+	"context"
+	"path"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+var _ handler.DirectoryProvider = (*s3)(nil)
+
+// GetDirectory はDelimiterを"/"として一覧を取得し、共通の接頭辞をディレクトリとして返す
+func (p *s3) GetDirectory(ctx context.Context, dirPath string) ([]handler.FileInfo, error) {
+	client, settings, err := p.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := dirPrefix(settings, dirPath)
+	items := []handler.FileInfo{}
+	for obj := range client.ListObjects(ctx, settings.Bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+		if obj.Err != nil {
+			return nil, convertError(obj.Err)
+		}
+		name := strings.TrimPrefix(obj.Key, prefix)
+		// ディレクトリを表す空のオブジェクトは除外する
+		if name == "" {
+			continue
+		}
+		item := handler.FileInfo{
+			Name:  path.Clean(name),
+			IsDir: strings.HasSuffix(name, "/"),
+		}
+		if !item.IsDir {
+			item.Size = obj.Size
+			item.ModTime = obj.LastModified
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,112 @@
+package s3
+
+import (
+	"backend/handler"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+var _ handler.DocumentContentProvider = (*s3)(nil)
+var _ handler.VersionedDocumentContentProvider = (*s3)(nil)
+var _ handler.DocumentContentUpdateProvider = (*s3)(nil)
+var _ handler.ConditionalDocumentContentUpdateProvider = (*s3)(nil)
+var _ handler.DocumentCreateProvider = (*s3)(nil)
+var _ handler.DocumentDeleteProvider = (*s3)(nil)
+
+func (p *s3) GetDocumentContent(ctx context.Context, docPath string) (string, error) {
+	content, _, err := p.GetDocumentContentVersion(ctx, docPath)
+	return content, err
+}
+
+// GetDocumentContentVersion はオブジェクトの内容とETagを返す
+func (p *s3) GetDocumentContentVersion(ctx context.Context, docPath string) (string, string, error) {
+	client, settings, err := p.bucket(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	obj, err := client.GetObject(ctx, settings.Bucket, objectKey(settings, docPath), minio.GetObjectOptions{})
+	if err != nil {
+		return "", "", convertError(err)
+	}
+	defer obj.Close()
+
+	info, err := obj.Stat()
+	if err != nil {
+		return "", "", convertError(err)
+	}
+	b, err := io.ReadAll(obj)
+	if err != nil {
+		return "", "", convertError(err)
+	}
+	return string(b), info.ETag, nil
+}
+
+func (p *s3) UpdateDocumentContent(ctx context.Context, docPath string, content string) error {
+	_, err := p.put(ctx, docPath, content, func(opts *minio.PutObjectOptions) {})
+	return err
+}
+
+// UpdateDocumentContentIfMatch はETagが一致する場合のみ更新する
+func (p *s3) UpdateDocumentContentIfMatch(ctx context.Context, docPath string, content string, version string) (string, error) {
+	return p.put(ctx, docPath, content, func(opts *minio.PutObjectOptions) {
+		opts.SetMatchETag(version)
+	})
+}
+
+// CreateDocument は同じキーのオブジェクトが存在しない場合のみ空のドキュメントを作成する
+func (p *s3) CreateDocument(ctx context.Context, docPath string) error {
+	_, err := p.put(ctx, docPath, "", func(opts *minio.PutObjectOptions) {
+		opts.SetMatchETagExcept("*")
+	})
+	if errors.Is(err, handler.ErrVersionMismatch) {
+		return handler.ErrDocumentExists
+	}
+	return err
+}
+
+func (p *s3) DeleteDocument(ctx context.Context, docPath string) error {
+	client, settings, err := p.bucket(ctx)
+	if err != nil {
+		return err
+	}
+	key := objectKey(settings, docPath)
+	if !isMarkdown(key) {
+		return fmt.Errorf("only markdown documents can be deleted: %s", docPath)
+	}
+	// S3は存在しないキーの削除も成功とするため、先に存在を確認する
+	if _, err := client.StatObject(ctx, settings.Bucket, key, minio.StatObjectOptions{}); err != nil {
+		return convertError(err)
+	}
+	return convertError(client.RemoveObject(ctx, settings.Bucket, key, minio.RemoveObjectOptions{}))
+}
+
+func (p *s3) put(ctx context.Context, docPath string, content string, condition func(*minio.PutObjectOptions)) (string, error) {
+	client, settings, err := p.bucket(ctx)
+	if err != nil {
+		return "", err
+	}
+	key := objectKey(settings, docPath)
+	if !isMarkdown(key) {
+		return "", fmt.Errorf("only markdown documents can be written: %s", docPath)
+	}
+
+	// HTTPの場合もチャンク形式の署名を使わずにContent-Lengthを付けて送る（S3互換サーバーによっては未対応のため）
+	// 内容の検証にはContent-MD5を使う
+	opts := minio.PutObjectOptions{
+		ContentType:          "text/markdown; charset=utf-8",
+		SendContentMd5:       true,
+		DisableContentSha256: true,
+	}
+	condition(&opts)
+	info, err := client.PutObject(ctx, settings.Bucket, key, strings.NewReader(content), int64(len(content)), opts)
+	if err != nil {
+		return "", convertError(err)
+	}
+	return info.ETag, nil
+}
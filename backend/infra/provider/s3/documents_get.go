@@ -0,0 +1,57 @@
+package s3
+
+import (
+	"backend/domain"
+	"backend/handler"
+	"context"
+	"path"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+var _ handler.DocumentsProvider = (*s3)(nil)
+
+// GetDocuments はdirPath配下のオブジェクトを再帰的に取得し、conditionに一致するものを返す
+func (p *s3) GetDocuments(ctx context.Context, dirPath string, condition handler.DocumentCondition) ([]domain.Document, error) {
+	client, settings, err := p.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := dirPrefix(settings, dirPath)
+	docs := []domain.Document{}
+	for obj := range client.ListObjects(ctx, settings.Bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, convertError(obj.Err)
+		}
+		name := strings.TrimPrefix(obj.Key, prefix)
+		if name == "" || strings.HasSuffix(name, "/") || excludedDir(name, condition) {
+			continue
+		}
+		docPath := docPath(settings, obj.Key)
+		if !condition.MatchesFile(path.Base(path.Dir(docPath)), path.Base(name)) {
+			continue
+		}
+		docs = append(docs, domain.Document{
+			Path: docPath,
+			Name: name,
+		})
+	}
+	return docs, nil
+}
+
+// excludedDir はnameの途中のディレクトリに除外するディレクトリが含まれるかを返す
+// S3にはディレクトリが無いため、走査でディレクトリを飛ばす代わりにキーのディレクトリ名で判定する
+func excludedDir(name string, condition handler.DocumentCondition) bool {
+	dir := path.Dir(name)
+	if dir == "." {
+		return false
+	}
+	for _, dirName := range strings.Split(dir, "/") {
+		if condition.ExcludesDir(dirName) {
+			return true
+		}
+	}
+	return false
+}
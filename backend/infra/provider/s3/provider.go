@@ -0,0 +1,156 @@
+package s3
+
+import (
+	"backend/config"
+	"backend/handler"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3互換のオブジェクトストレージのBucketとPrefixをドキュメントのディレクトリとして扱う
+// ドキュメントのパスは"/"をPrefixとする絶対パスで表す（例: /design/overview.md）
+type s3 struct {
+	appConfigProvider config.AppConfigProvider
+
+	mu       sync.Mutex
+	settings config.S3
+	client   *minio.Client
+}
+
+var _ handler.HealthChecker = (*s3)(nil)
+
+// NewS3Provider は設定のS3を取得元とするプロバイダーを返す
+// 設定が変更された場合は次の操作から新しい設定で接続する
+func NewS3Provider(appConfigProvider config.AppConfigProvider) *s3 {
+	return &s3{appConfigProvider: appConfigProvider}
+}
+
+// errNotConfigured はS3の取得元が設定されていないことを表す
+var errNotConfigured = errors.New("s3 is not configured")
+
+// bucket は現在の設定のクライアントと設定を返す
+func (p *s3) bucket(ctx context.Context) (*minio.Client, config.S3, error) {
+	appConfig, err := p.appConfigProvider.Load(ctx)
+	if err != nil {
+		return nil, config.S3{}, err
+	}
+	settings := appConfig.S3
+	if !settings.Configured() {
+		return nil, settings, errNotConfigured
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != nil && p.settings == settings {
+		return p.client, settings, nil
+	}
+
+	var creds *credentials.Credentials
+	if settings.AccessKeyID != "" {
+		creds = credentials.NewStaticV4(settings.AccessKeyID, settings.SecretAccessKey.Reveal(), "")
+	} else {
+		// 認証情報が設定されていない場合は環境変数などから取得する
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.EnvMinio{},
+			&credentials.IAM{},
+		})
+	}
+
+	lookup := minio.BucketLookupAuto
+	if settings.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(settings.Endpoint, &minio.Options{
+		Creds:        creds,
+		Secure:       !settings.Insecure,
+		Region:       settings.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, settings, err
+	}
+
+	p.settings = settings
+	p.client = client
+	return client, settings, nil
+}
+
+func (p *s3) Name() string {
+	return "s3"
+}
+
+// CheckHealth はバケットにアクセスできるかを確認する
+func (p *s3) CheckHealth(ctx context.Context) error {
+	client, settings, err := p.bucket(ctx)
+	if errors.Is(err, errNotConfigured) {
+		return handler.ErrHealthCheckSkipped
+	}
+	if err != nil {
+		return err
+	}
+	exists, err := client.BucketExists(ctx, settings.Bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("bucket %s does not exist", settings.Bucket)
+	}
+	return nil
+}
+
+// objectKey はドキュメントのパスをオブジェクトのキーに変換する
+func objectKey(settings config.S3, docPath string) string {
+	rel := strings.TrimPrefix(path.Clean("/"+docPath), "/")
+	return rootPrefix(settings) + rel
+}
+
+// dirPrefix はディレクトリのパスを一覧取得用のキーの接頭辞に変換する
+func dirPrefix(settings config.S3, dirPath string) string {
+	key := objectKey(settings, dirPath)
+	if key == "" || strings.HasSuffix(key, "/") {
+		return key
+	}
+	return key + "/"
+}
+
+// docPath はオブジェクトのキーをドキュメントのパスに変換する
+func docPath(settings config.S3, key string) string {
+	return "/" + strings.TrimPrefix(key, rootPrefix(settings))
+}
+
+func rootPrefix(settings config.S3) string {
+	prefix := strings.Trim(settings.Prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return prefix + "/"
+}
+
+// isMarkdown は書き込みを許可するオブジェクトかを返す
+func isMarkdown(key string) bool {
+	return strings.HasSuffix(strings.ToLower(key), ".md")
+}
+
+// convertError はS3のエラーをハンドラーが扱うエラーに変換する
+func convertError(err error) error {
+	if err == nil {
+		return nil
+	}
+	resp := minio.ToErrorResponse(err)
+	switch resp.Code {
+	case "PreconditionFailed":
+		return handler.ErrVersionMismatch
+	case "NoSuchKey":
+		return fmt.Errorf("%w: %s", fs.ErrNotExist, resp.Message)
+	}
+	return err
+}
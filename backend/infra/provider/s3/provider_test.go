@@ -0,0 +1,189 @@
+package s3
+
+import (
+	"backend/config"
+	"backend/config/configtest"
+	"backend/handler"
+	"context"
+	"errors"
+	"io/fs"
+	"net/http/httptest"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/minio/minio-go/v7"
+)
+
+// newTestProvider はメモリ上のS3互換サーバーに接続するプロバイダーを返す
+func newTestProvider(t *testing.T, objects map[string]string) *s3 {
+	t.Helper()
+	backend := s3mem.New()
+	server := httptest.NewServer(gofakes3.New(backend).Server())
+	t.Cleanup(server.Close)
+
+	const bucket = "docs"
+	if err := backend.CreateBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := config.S3{
+		Endpoint:        strings.TrimPrefix(server.URL, "http://"),
+		Region:          "us-east-1",
+		Bucket:          bucket,
+		Prefix:          "wiki/",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		Insecure:        true,
+		PathStyle:       true,
+	}
+	p := NewS3Provider(configtest.NewProvider(&config.AppConfig{S3: settings}))
+
+	client, _, err := p.bucket(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, content := range objects {
+		if _, err := client.PutObject(context.Background(), bucket, key, strings.NewReader(content), int64(len(content)), minio.PutObjectOptions{DisableContentSha256: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return p
+}
+
+func TestGetDocuments(t *testing.T) {
+	p := newTestProvider(t, map[string]string{
+		"wiki/index.md":            "# Index",
+		"wiki/design/overview.md":  "# Overview",
+		"wiki/design/diagram.png":  "png",
+		"wiki/node_modules/dep.md": "# Dep",
+		"other/outside.md":         "# Outside",
+		"wiki/design/":             "",
+	})
+
+	docs, err := p.GetDocuments(context.Background(), "/", handler.DefaultDocumentCondition())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, doc := range docs {
+		paths = append(paths, doc.Path)
+	}
+	slices.Sort(paths)
+	if want := []string{"/design/overview.md", "/index.md"}; !slices.Equal(paths, want) {
+		t.Errorf("documents = %v, want %v", paths, want)
+	}
+
+	items, err := p.GetDirectory(context.Background(), "/design")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	slices.Sort(names)
+	if want := []string{"diagram.png", "overview.md"}; !slices.Equal(names, want) {
+		t.Errorf("directory = %v, want %v", names, want)
+	}
+}
+
+func TestGetDocumentsUsesTheSameConditionAsLocal(t *testing.T) {
+	p := newTestProvider(t, map[string]string{
+		"wiki/notes/readme.md":       "",
+		"wiki/notes/draft.tmp.md":    "",
+		"wiki/notes/upper.MD":        "",
+		"wiki/notes/todo.txt":        "",
+		"wiki/docs/guide.md":         "",
+		"wiki/docs/.git/config.md":   "",
+		"wiki/build-cache/docs/a.md": "",
+		"wiki/docs/build-out/out.md": "",
+		"wiki/other/skip.md":         "",
+	})
+
+	condition := handler.DocumentCondition{
+		Includes: handler.Condition{Exts: []string{"md", "txt"}, DirNames: []string{"notes", "docs"}},
+		Excludes: handler.Condition{Exts: []string{"tmp.md"}, DirNames: []string{".git", "build*"}},
+	}
+	docs, err := p.GetDocuments(context.Background(), "/", condition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, doc := range docs {
+		paths = append(paths, doc.Path)
+		if local := condition.MatchesFile(path.Base(path.Dir(doc.Path)), path.Base(doc.Path)); !local {
+			t.Errorf("%s does not match the condition of the local provider", doc.Path)
+		}
+	}
+	slices.Sort(paths)
+	if want := []string{"/docs/guide.md", "/notes/readme.md", "/notes/todo.txt"}; !slices.Equal(paths, want) {
+		t.Errorf("documents = %v, want %v", paths, want)
+	}
+}
+
+func TestUpdateDocumentContentIfMatch(t *testing.T) {
+	p := newTestProvider(t, map[string]string{"wiki/index.md": "old"})
+	ctx := context.Background()
+
+	content, version, err := p.GetDocumentContentVersion(ctx, "/index.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "old" || version == "" {
+		t.Fatalf("content = %q, version = %q", content, version)
+	}
+
+	newVersion, err := p.UpdateDocumentContentIfMatch(ctx, "/index.md", "new", version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newVersion == version {
+		t.Errorf("version was not changed by the update")
+	}
+
+	// 読み込んだ後に更新されている場合は上書きしない
+	if _, err := p.UpdateDocumentContentIfMatch(ctx, "/index.md", "stale", version); !errors.Is(err, handler.ErrVersionMismatch) {
+		t.Errorf("update with stale version = %v, want ErrVersionMismatch", err)
+	}
+	if content, _ := p.GetDocumentContent(ctx, "/index.md"); content != "new" {
+		t.Errorf("content = %q, want new", content)
+	}
+}
+
+func TestCreateDocument(t *testing.T) {
+	p := newTestProvider(t, map[string]string{"wiki/index.md": "keep"})
+	ctx := context.Background()
+
+	if err := p.CreateDocument(ctx, "/notes/new.md"); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := p.GetDocumentContent(ctx, "/notes/new.md"); err != nil || content != "" {
+		t.Errorf("created content = %q, %v", content, err)
+	}
+
+	if err := p.CreateDocument(ctx, "/index.md"); !errors.Is(err, handler.ErrDocumentExists) {
+		t.Errorf("create existing = %v, want ErrDocumentExists", err)
+	}
+	if content, _ := p.GetDocumentContent(ctx, "/index.md"); content != "keep" {
+		t.Errorf("existing content was overwritten: %q", content)
+	}
+}
+
+func TestDeleteDocument(t *testing.T) {
+	p := newTestProvider(t, map[string]string{"wiki/index.md": "# Index"})
+	ctx := context.Background()
+
+	if err := p.DeleteDocument(ctx, "/index.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.GetDocumentContent(ctx, "/index.md"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("get deleted = %v, want fs.ErrNotExist", err)
+	}
+	if err := p.DeleteDocument(ctx, "/index.md"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("delete missing = %v, want fs.ErrNotExist", err)
+	}
+}
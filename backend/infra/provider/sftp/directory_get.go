@@ -0,0 +1,34 @@
+package sftp
+
+import (
+	"backend/handler"
+	"context"
+)
+
+var _ handler.DirectoryProvider = (*remote)(nil)
+
+func (p *remote) GetDirectory(ctx context.Context, dirPath string) ([]handler.FileInfo, error) {
+	client, name, err := p.open(ctx, dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := client.ReadDirContext(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]handler.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		item := handler.FileInfo{
+			Name:    entry.Name(),
+			IsDir:   entry.IsDir(),
+			ModTime: entry.ModTime(),
+		}
+		if !item.IsDir {
+			item.Size = entry.Size()
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,140 @@
+package sftp
+
+import (
+	"backend/handler"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	sftpclient "github.com/pkg/sftp"
+)
+
+var _ handler.DocumentContentProvider = (*remote)(nil)
+var _ handler.DocumentContentUpdateProvider = (*remote)(nil)
+var _ handler.DocumentCreateProvider = (*remote)(nil)
+var _ handler.DocumentDeleteProvider = (*remote)(nil)
+
+// posixRenameExtension は上書きできる名前の変更に対応しているサーバーが通知する拡張
+const posixRenameExtension = "posix-rename@openssh.com"
+
+func (p *remote) GetDocumentContent(ctx context.Context, docPath string) (string, error) {
+	client, name, err := p.open(ctx, docPath)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := client.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	b, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// UpdateDocumentContent は同じディレクトリの一時ファイルに書き込んでから置き換える
+// 書き込みの途中で接続が切れても元の内容が失われないようにする
+func (p *remote) UpdateDocumentContent(ctx context.Context, docPath string, content string) error {
+	client, name, err := p.open(ctx, docPath)
+	if err != nil {
+		return err
+	}
+	if !isMarkdown(name) {
+		return fmt.Errorf("only markdown documents can be written: %s", docPath)
+	}
+
+	// 置き換えに対応していないサーバーでは直接書き込む
+	if _, ok := client.HasExtension(posixRenameExtension); !ok {
+		return writeFile(client, name, content, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	}
+
+	tmp, err := tempName(name)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(client, tmp, content, os.O_WRONLY|os.O_CREATE|os.O_EXCL); err != nil {
+		client.Remove(tmp)
+		return err
+	}
+	// 既存のファイルの権限を引き継ぐ
+	if info, err := client.Stat(name); err == nil {
+		if err := client.Chmod(tmp, info.Mode().Perm()); err != nil {
+			client.Remove(tmp)
+			return err
+		}
+	}
+	if err := client.PosixRename(tmp, name); err != nil {
+		client.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func writeFile(client *sftpclient.Client, name string, content string, flag int) error {
+	file, err := client.OpenFile(name, flag)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write([]byte(content)); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// tempName はnameと同じディレクトリの隠しファイルの名前を返す
+func tempName(name string) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	dir, file := path.Split(name)
+	return path.Join(dir, "."+file+"."+hex.EncodeToString(b)+".tmp"), nil
+}
+
+// CreateDocument は親ディレクトリを作成し、同じパスのファイルが存在しない場合のみ空のドキュメントを作成する
+func (p *remote) CreateDocument(ctx context.Context, docPath string) error {
+	client, name, err := p.open(ctx, docPath)
+	if err != nil {
+		return err
+	}
+	if !isMarkdown(name) {
+		return fmt.Errorf("only markdown documents can be created: %s", docPath)
+	}
+
+	// SFTPv3ではO_EXCLで失敗した理由を区別できないため先に存在を確認する
+	if _, err := client.Stat(name); err == nil {
+		return handler.ErrDocumentExists
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := client.MkdirAll(path.Dir(name)); err != nil {
+		return err
+	}
+
+	file, err := client.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func (p *remote) DeleteDocument(ctx context.Context, docPath string) error {
+	client, name, err := p.open(ctx, docPath)
+	if err != nil {
+		return err
+	}
+	if !isMarkdown(name) {
+		return fmt.Errorf("only markdown documents can be deleted: %s", docPath)
+	}
+	return client.Remove(name)
+}
@@ -0,0 +1,52 @@
+package sftp
+
+import (
+	"backend/domain"
+	"backend/handler"
+	"context"
+	"path"
+	"strings"
+)
+
+var _ handler.DocumentsProvider = (*remote)(nil)
+
+// GetDocuments はdirPath配下をローカルのプロバイダーと同じ条件で再帰的に走査する
+func (p *remote) GetDocuments(ctx context.Context, dirPath string, condition handler.DocumentCondition) ([]domain.Document, error) {
+	client, root, err := p.open(ctx, dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := []domain.Document{}
+	walker := client.Walk(root)
+	for walker.Step() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		filePath := walker.Path()
+		if err := walker.Err(); err != nil {
+			// 読めないディレクトリは無視して走査を続ける
+			if filePath == root {
+				return nil, err
+			}
+			continue
+		}
+
+		info := walker.Stat()
+		if info.IsDir() {
+			if filePath != root && condition.ExcludesDir(info.Name()) {
+				walker.SkipDir()
+			}
+			continue
+		}
+		if !condition.MatchesFile(path.Base(path.Dir(filePath)), info.Name()) {
+			continue
+		}
+		docs = append(docs, domain.Document{
+			Path: filePath,
+			Name: strings.TrimPrefix(filePath, strings.TrimSuffix(root, "/")+"/"),
+		})
+	}
+	return docs, nil
+}
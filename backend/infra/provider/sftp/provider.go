@@ -0,0 +1,244 @@
+package sftp
+
+import (
+	"backend/config"
+	"backend/handler"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	sftpclient "github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SSHで接続したリモートマシンのディレクトリをドキュメントのディレクトリとして扱う
+// ドキュメントのパスはリモートマシン上の絶対パスで表す（例: /srv/docs/overview.md）
+// 設定のRootの外のパスは読み書きしない
+type remote struct {
+	appConfigProvider config.AppConfigProvider
+	// connect は設定に従って接続する（テストでは置き換える）
+	connect func(ctx context.Context, settings config.SFTP) (*sftpclient.Client, io.Closer, error)
+
+	mu       sync.Mutex
+	settings config.SFTP
+	conn     io.Closer
+	client   *sftpclient.Client
+}
+
+var _ handler.HealthChecker = (*remote)(nil)
+
+// NewSFTPProvider は設定のSFTPを取得元とするプロバイダーを返す
+// 設定が変更された場合や接続が切れた場合は次の操作で接続し直す
+func NewSFTPProvider(appConfigProvider config.AppConfigProvider) *remote {
+	return &remote{appConfigProvider: appConfigProvider, connect: connectSSH}
+}
+
+// errNotConfigured はSFTPの取得元が設定されていないことを表す
+var errNotConfigured = errors.New("sftp is not configured")
+
+// errOutsideRoot は設定のRootの外のパスを指定されたことを表す
+var errOutsideRoot = fmt.Errorf("path is outside of the sftp root: %w", fs.ErrPermission)
+
+// 接続の確立を待つ時間
+const dialTimeout = 10 * time.Second
+
+// session は現在の設定で接続済みのクライアントと設定を返す
+func (p *remote) session(ctx context.Context) (*sftpclient.Client, config.SFTP, error) {
+	appConfig, err := p.appConfigProvider.Load(ctx)
+	if err != nil {
+		return nil, config.SFTP{}, err
+	}
+	settings := appConfig.SFTP
+	if !settings.Configured() {
+		return nil, settings, errNotConfigured
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != nil && p.settings == settings {
+		return p.client, settings, nil
+	}
+	if p.client != nil {
+		p.closeLocked()
+	}
+
+	client, conn, err := p.connect(ctx, settings)
+	if err != nil {
+		return nil, settings, err
+	}
+
+	p.settings = settings
+	p.conn = conn
+	p.client = client
+
+	// 接続が切れた場合は次の操作で接続し直す
+	go func() {
+		client.Wait()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.client == client {
+			p.closeLocked()
+		}
+	}()
+	return client, settings, nil
+}
+
+// open は接続済みのクライアントとドキュメントのリモートマシン上のパスを返す
+func (p *remote) open(ctx context.Context, docPath string) (*sftpclient.Client, string, error) {
+	client, settings, err := p.session(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	name, err := remotePath(settings, docPath)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, name, nil
+}
+
+// connectSSH はSSHで接続し、SFTPのクライアントと切断に使うSSHの接続を返す
+func connectSSH(ctx context.Context, settings config.SFTP) (*sftpclient.Client, io.Closer, error) {
+	conn, err := dial(ctx, settings)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := sftpclient.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return client, conn, nil
+}
+
+// closeLocked は呼び出し側でロックを取得している前提
+func (p *remote) closeLocked() {
+	p.client.Close()
+	p.conn.Close()
+	p.client = nil
+	p.conn = nil
+}
+
+// Close は接続を切断する
+func (p *remote) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != nil {
+		p.closeLocked()
+	}
+	return nil
+}
+
+func dial(ctx context.Context, settings config.SFTP) (*ssh.Client, error) {
+	key, err := os.ReadFile(expandHome(settings.KeyPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	var signer ssh.Signer
+	if settings.KeyPassphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(settings.KeyPassphrase.Reveal()))
+	} else {
+		signer, err = ssh.ParsePrivateKey(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	knownHostsPath := settings.KnownHostsPath
+	if knownHostsPath == "" {
+		knownHostsPath = "~/.ssh/known_hosts"
+	}
+	hostKeyCallback, err := knownhosts.New(expandHome(knownHostsPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known_hosts: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	addr := settings.Addr()
+	var dialer net.Dialer
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	// ハンドシェイクもタイムアウトさせる
+	if deadline, ok := ctx.Deadline(); ok {
+		netConn.SetDeadline(deadline)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, addr, &ssh.ClientConfig{
+		User:            settings.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	netConn.SetDeadline(time.Time{})
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// expandHome は先頭の~をホームディレクトリに置き換える
+func expandHome(p string) string {
+	rest, ok := strings.CutPrefix(p, "~/")
+	if !ok {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, rest)
+}
+
+func (p *remote) Name() string {
+	return "sftp"
+}
+
+// CheckHealth はリモートマシンに接続できるかを確認する
+func (p *remote) CheckHealth(ctx context.Context) error {
+	client, settings, err := p.session(ctx)
+	if errors.Is(err, errNotConfigured) {
+		return handler.ErrHealthCheckSkipped
+	}
+	if err != nil {
+		return err
+	}
+	info, err := client.Stat(rootPath(settings))
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("root %s is not a directory", settings.Root)
+	}
+	return nil
+}
+
+// remotePath はドキュメントのパスをリモートマシン上のパスに正規化する
+// Rootの外のパスはerrOutsideRootを返す
+func remotePath(settings config.SFTP, docPath string) (string, error) {
+	name := path.Clean("/" + docPath)
+	root := rootPath(settings)
+	if name != root && !strings.HasPrefix(name, strings.TrimSuffix(root, "/")+"/") {
+		return "", fmt.Errorf("%s: %w", docPath, errOutsideRoot)
+	}
+	return name, nil
+}
+
+func rootPath(settings config.SFTP) string {
+	return path.Clean("/" + settings.Root)
+}
+
+// isMarkdown は書き込みを許可するファイルかを返す
+func isMarkdown(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".md")
+}
@@ -0,0 +1,162 @@
+package sftp
+
+import (
+	"backend/config"
+	"backend/config/configtest"
+	"backend/handler"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	sftpclient "github.com/pkg/sftp"
+)
+
+// newTestProvider はnet.Pipeで接続したSFTPサーバーを使うプロバイダーを返す
+// サーバーはローカルのファイルシステムをそのまま公開する
+func newTestProvider(t *testing.T, root string) *remote {
+	t.Helper()
+	settings := config.SFTP{Host: "docs.example.com", User: "deploy", KeyPath: "~/.ssh/id_ed25519", Root: root}
+	p := NewSFTPProvider(configtest.NewProvider(&config.AppConfig{SFTP: settings}))
+	p.connect = func(ctx context.Context, settings config.SFTP) (*sftpclient.Client, io.Closer, error) {
+		clientConn, serverConn := net.Pipe()
+		server, err := sftpclient.NewServer(serverConn)
+		if err != nil {
+			return nil, nil, err
+		}
+		go server.Serve()
+		client, err := sftpclient.NewClientPipe(clientConn, clientConn)
+		if err != nil {
+			server.Close()
+			return nil, nil, err
+		}
+		return client, server, nil
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func writeLocalFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetDocuments(t *testing.T) {
+	root := t.TempDir()
+	writeLocalFile(t, filepath.Join(root, "index.md"), "# Index")
+	writeLocalFile(t, filepath.Join(root, "design", "overview.md"), "# Overview")
+	writeLocalFile(t, filepath.Join(root, "design", "diagram.png"), "png")
+	writeLocalFile(t, filepath.Join(root, "node_modules", "dep.md"), "# Dep")
+	p := newTestProvider(t, root)
+
+	docs, err := p.GetDocuments(context.Background(), root, handler.DefaultDocumentCondition())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, doc := range docs {
+		names = append(names, doc.Name)
+	}
+	slices.Sort(names)
+	if want := []string{"design/overview.md", "index.md"}; !slices.Equal(names, want) {
+		t.Errorf("documents = %v, want %v", names, want)
+	}
+}
+
+func TestUpdateDocumentContentReplacesFile(t *testing.T) {
+	root := t.TempDir()
+	name := filepath.Join(root, "index.md")
+	writeLocalFile(t, name, "old content that is longer than the new one")
+	if err := os.Chmod(name, 0600); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newTestProvider(t, root)
+
+	if err := p.UpdateDocumentContent(context.Background(), name, "new"); err != nil {
+		t.Fatal(err)
+	}
+	// 元のファイルに書き込まず、書き込み済みのファイルで置き換える
+	if after, err := os.Stat(name); err != nil || os.SameFile(before, after) {
+		t.Errorf("file was written in place")
+	}
+	if b, _ := os.ReadFile(name); string(b) != "new" {
+		t.Errorf("content = %q, want new", b)
+	}
+	if info, err := os.Stat(name); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+	// 一時ファイルは残らない
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("entries = %v, want only index.md", entries)
+	}
+}
+
+func TestCreateAndDeleteDocument(t *testing.T) {
+	root := t.TempDir()
+	p := newTestProvider(t, root)
+	ctx := context.Background()
+	name := filepath.Join(root, "notes", "new.md")
+
+	if err := p.CreateDocument(ctx, name); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.CreateDocument(ctx, name); !errors.Is(err, handler.ErrDocumentExists) {
+		t.Errorf("create existing = %v, want ErrDocumentExists", err)
+	}
+	if err := p.DeleteDocument(ctx, name); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.DeleteDocument(ctx, name); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("delete missing = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestPathsOutsideRootAreRejected(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "docs")
+	outside := filepath.Join(dir, "docs-private", "secret.md")
+	writeLocalFile(t, filepath.Join(root, "index.md"), "# Index")
+	writeLocalFile(t, outside, "secret")
+	p := newTestProvider(t, root)
+	ctx := context.Background()
+
+	for _, docPath := range []string{outside, filepath.Join(root, "..", "docs-private", "secret.md")} {
+		if _, err := p.GetDocumentContent(ctx, docPath); !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("GetDocumentContent(%s) = %v, want fs.ErrPermission", docPath, err)
+		}
+		if err := p.UpdateDocumentContent(ctx, docPath, "overwritten"); !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("UpdateDocumentContent(%s) = %v, want fs.ErrPermission", docPath, err)
+		}
+		if err := p.DeleteDocument(ctx, docPath); !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("DeleteDocument(%s) = %v, want fs.ErrPermission", docPath, err)
+		}
+	}
+	if _, err := p.GetDirectory(ctx, "/"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("GetDirectory(/) = %v, want fs.ErrPermission", err)
+	}
+	if b, _ := os.ReadFile(outside); string(b) != "secret" {
+		t.Errorf("file outside of the root was changed: %q", b)
+	}
+
+	if content, err := p.GetDocumentContent(ctx, filepath.Join(root, "index.md")); err != nil || content != "# Index" {
+		t.Errorf("GetDocumentContent inside the root = %q, %v", content, err)
+	}
+}
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type requestKey struct{}
+
+// request はリクエストごとのロガーとアクセスログに追加する属性を保持する
+type request struct {
+	id     string
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// WithRequest はリクエストIDを付与したロガーをctxに設定する
+func WithRequest(ctx context.Context, logger *slog.Logger, requestID string) context.Context {
+	return context.WithValue(ctx, requestKey{}, &request{
+		id:     requestID,
+		logger: logger.With(slog.String("request_id", requestID)),
+	})
+}
+
+// FromContext はリクエストIDが付与されたロガーを返す
+// リクエスト外で呼ばれた場合は既定のロガーを返す
+func FromContext(ctx context.Context) *slog.Logger {
+	if r, ok := ctx.Value(requestKey{}).(*request); ok {
+		return r.logger
+	}
+	return slog.Default()
+}
+
+// RequestID はctxのリクエストIDを返す
+func RequestID(ctx context.Context) string {
+	if r, ok := ctx.Value(requestKey{}).(*request); ok {
+		return r.id
+	}
+	return ""
+}
+
+// AddAttrs はリクエストの完了時に出力するアクセスログに属性を追加する
+func AddAttrs(ctx context.Context, attrs ...slog.Attr) {
+	r, ok := ctx.Value(requestKey{}).(*request)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attrs = append(r.attrs, attrs...)
+}
+
+// Attrs はAddAttrsで追加された属性を返す
+func Attrs(ctx context.Context) []slog.Attr {
+	r, ok := ctx.Value(requestKey{}).(*request)
+	if !ok {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]slog.Attr{}, r.attrs...)
+}
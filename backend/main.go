@@ -1,95 +1,386 @@
 package main
 
 import (
+	"backend/cli"
 	"backend/config"
 	"backend/config/mode"
+	"backend/demo"
+	"backend/domain"
 	"backend/handler"
+	"backend/infra/filesystem"
+	"backend/infra/health"
 	"backend/infra/provider/local"
+	"backend/infra/provider/plugin"
+	"backend/infra/provider/s3"
+	"backend/infra/provider/sftp"
 	"backend/middleware"
 	"backend/util"
 
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
 	"fmt"
+	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 )
 
-func getConfigProvider() (config.AppConfigProvider, error) {
-	appMode, err := config.ParseAppMode(util.LookupEnvOr("APP_MODE", config.CLI.String()))
-	if err != nil {
-		return nil, err
-	}
+const (
+	// インデックスの差分更新間隔
+	indexRefreshInterval = 30 * time.Second
+	// 設定ファイルの変更の確認間隔
+	configWatchInterval = 2 * time.Second
+)
+
+func getConfigProvider(appMode config.AppMode) (config.AppConfigProvider, error) {
+	// 設定ファイル内の秘密情報を暗号化する場合のパスフレーズ
+	passphrase := util.LookupEnvOr("REPO_WISE_CONFIG_PASSPHRASE", "")
 
 	if appMode == config.CLI || appMode == config.Native {
-		return mode.NewLocalProvider()
+		return mode.NewLocalProvider(passphrase)
+	}
+
+	if appMode == config.Web {
+		configDir, err := util.UserConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		return mode.NewWebProvider(
+			util.LookupEnvOr("WEB_DATA_DIR", filepath.Join(configDir, "repo-wise", "web")),
+			util.SplitList(util.LookupEnvOr("WEB_ALLOWED_ROOTS", "")),
+			passphrase,
+		)
+	}
+
+	if appMode == config.Demo {
+		return mode.NewDemoProvider(demo.Root), nil
 	}
 
 	return nil, fmt.Errorf("unsupported app mode: %s", appMode)
 }
 
+// newFileSystem はローカルのプロバイダーが読み書きするファイルシステムを返す
+// デモではサンプルのドキュメントをメモリ上に展開し、DEMO_READ_ONLYの場合は埋め込んだまま読み取り専用で配置する
+func newFileSystem(appMode config.AppMode) (filesystem.FS, error) {
+	if appMode != config.Demo {
+		return filesystem.OS(), nil
+	}
+
+	docs, err := demo.Docs()
+	if err != nil {
+		return nil, err
+	}
+	readOnly, err := strconv.ParseBool(util.LookupEnvOr("DEMO_READ_ONLY", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DEMO_READ_ONLY: %w", err)
+	}
+	if readOnly {
+		return filesystem.NewReadOnly(demo.Root, docs), nil
+	}
+
+	memory := filesystem.NewMemory()
+	if err := filesystem.CopyFS(memory, demo.Root, docs); err != nil {
+		return nil, err
+	}
+	return memory, nil
+}
+
 func main() {
+	app := &cli.App{
+		Serve:  serve,
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+
+	if err := runCLI(app); err != nil {
+		if !errors.Is(err, cli.ErrUsage) && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// runCLI はコマンドを実行する
+// serve以外のコマンドはローカルの設定と、serveと同じプロバイダーを使用する
+func runCLI(app *cli.App) error {
+	ctx := context.Background()
+	if len(os.Args) > 1 && os.Args[1] != "serve" {
+		configProvider, err := getConfigProvider(config.CLI)
+		if err != nil {
+			return err
+		}
+		// 設定が読めない場合もconfigコマンドなどで修正できるように、プラグイン以外のプロバイダーで続ける
+		var plugins []config.Plugin
+		if appConfig, err := configProvider.Load(ctx); err == nil {
+			plugins = appConfig.Plugins
+		}
+		fsys := filesystem.OS()
+		localRepoProvider, err := local.NewLocalProvider(fsys)
+		if err != nil {
+			return err
+		}
+		registry, _, closeProviders, err := newProviderRegistry(ctx, fsys, localRepoProvider, configProvider, plugins, slog.Default())
+		if err != nil {
+			return err
+		}
+		defer closeProviders()
+
+		app.ConfigProvider = configProvider
+		app.Providers = registry
+	}
+	return app.Run(ctx, os.Args[1:])
+}
+
+// newProviderRegistry はserveとCLIのコマンドで共通のプロバイダーを登録し、外部サービスの確認対象と共に返す
+// 設定ファイルに記載された外部のプロバイダー（プラグイン）も起動する
+// closeProvidersで接続やプラグインを終了する
+func newProviderRegistry(ctx context.Context, fsys filesystem.FS, localRepoProvider any, configProvider config.AppConfigProvider, plugins []config.Plugin, logger *slog.Logger) (registry *handler.ProviderRegistry, healthCheckers []handler.HealthChecker, closeProviders func(), err error) {
+	var closers []func() error
+	closeProviders = func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			_ = closers[i]()
+		}
+	}
+	defer func() {
+		if err != nil {
+			closeProviders()
+		}
+	}()
+
+	registry = handler.NewProviderRegistry()
+	if err := registry.Register(domain.LocalRepoKind, localRepoProvider); err != nil {
+		return nil, nil, nil, err
+	}
+	// アーカイブの中は同じファイルシステムを読み取り専用で参照する
+	archiveProvider, err := local.NewLocalProvider(filesystem.NewArchive(fsys))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := registry.Register(domain.ArchiveRepoKind, archiveProvider); err != nil {
+		return nil, nil, nil, err
+	}
+	s3Provider := s3.NewS3Provider(configProvider)
+	if err := registry.Register(domain.S3RepoKind, s3Provider); err != nil {
+		return nil, nil, nil, err
+	}
+	sftpProvider := sftp.NewSFTPProvider(configProvider)
+	closers = append(closers, sftpProvider.Close)
+	if err := registry.Register(domain.SFTPRepoKind, sftpProvider); err != nil {
+		return nil, nil, nil, err
+	}
+
+	healthCheckers = []handler.HealthChecker{
+		health.NewGithubChecker(
+			health.NewGithubClient(http.DefaultClient, util.LookupEnvOr("GITHUB_API_URL", "https://api.github.com")),
+			configProvider,
+		),
+		s3Provider,
+		sftpProvider,
+	}
+	// 起動に失敗したプラグインは登録せずに続ける
+	for _, pluginConfig := range plugins {
+		p, err := plugin.Start(ctx, pluginConfig, logger)
+		if err != nil {
+			log.Printf("Failed to start plugin: %v", err)
+			continue
+		}
+		closers = append(closers, p.Close)
+		if err := registry.Register(p.Kind(), p); err != nil {
+			return nil, nil, nil, err
+		}
+		healthCheckers = append(healthCheckers, p)
+	}
+	return registry, healthCheckers, closeProviders, nil
+}
+
+// serve はHTTPサーバーを起動する
+func serve(ctx context.Context) error {
 	serverConfig, err := config.NewServerConfig()
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	logConfig, err := config.NewLogConfig()
+	if err != nil {
+		return err
+	}
+	logger := logConfig.NewLogger(os.Stderr)
+	slog.SetDefault(logger)
+
+	authConfig, err := config.NewAuthConfig()
+	if err != nil {
+		return err
+	}
+
+	appMode, err := config.ParseAppMode(util.LookupEnvOr("APP_MODE", config.CLI.String()))
+	if err != nil {
+		return err
 	}
 
-	configProvider, err := getConfigProvider()
+	// webモードではユーザーごとに設定を分けるため認証が必須
+	if appMode == config.Web {
+		if authConfig.Mode == config.AuthDisabled {
+			return errors.New("web mode requires authentication")
+		}
+		authConfig.Mode = config.AuthRequired
+	}
+
+	configProvider, err := getConfigProvider(appMode)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	appConfig, err := configProvider.Load()
+	// 設定ファイルが不正でも画面から修正できるように空の設定で起動する
+	appConfig, loadErr := configProvider.Load(ctx)
+	if loadErr != nil {
+		log.Printf("Failed to load configuration, starting with an empty one: %v", loadErr)
+		appConfig = &config.AppConfig{AppMode: appMode}
+	}
+
+	fsys, err := newFileSystem(appMode)
+	if err != nil {
+		return err
+	}
+	localRepoProvider, err := local.NewLocalProvider(fsys)
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	// SIGINT/SIGTERMを受け取ったら終了処理を行う
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// バックグラウンド処理はHTTPサーバーの停止後に止める
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	var wg sync.WaitGroup
+
+	configStore := config.NewStore(configProvider, appConfig, loadErr)
+
+	// インデックスはディスクに保存するため、デモでは作成せずに毎回走査する
+	if appMode != config.Demo {
+		configDir, err := util.UserConfigDir()
+		if err != nil {
+			return err
+		}
+		indexStoreDir := filepath.Join(configDir, "repo-wise", "index")
+		localRepoProvider.EnableIndex(indexStoreDir, appConfig.LocalFile.Directories, handler.DocumentConditionFor(appConfig))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			localRepoProvider.RunIndexer(backgroundCtx, indexRefreshInterval)
+		}()
+		configStore.Subscribe(func(appConfig *config.AppConfig) {
+			localRepoProvider.EnableIndex(indexStoreDir, appConfig.LocalFile.Directories, handler.DocumentConditionFor(appConfig))
+		})
 	}
 
-	fmt.Printf("Loaded app config: %+v\n", appConfig)
+	// 設定ファイルの変更を監視し、インデックス対象のディレクトリなどに反映する
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		configStore.Watch(backgroundCtx, configWatchInterval)
+	}()
 
-	localRepoProvider, err := local.NewLocalProvider()
+	registry, healthCheckers, closeProviders, err := newProviderRegistry(ctx, fsys, localRepoProvider, configStore, appConfig.Plugins, logger)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	defer closeProviders()
 
 	router, err := handler.NewHandler(
 		appConfig.AppMode,
-		configProvider,
-		[]handler.DocumentsProvider{
-			localRepoProvider,
-		},
-		[]handler.DirectoryProvider{
-			localRepoProvider,
-		},
-		[]handler.DocumentContentProvider{
-			localRepoProvider,
-		},
-		[]handler.DocumentContentUpdateProvider{
-			localRepoProvider,
-		},
-		[]handler.DocumentCreateProvider{
-			localRepoProvider,
-		},
-		[]handler.DocumentDeleteProvider{
-			localRepoProvider,
-		},
-		middleware.NewLogger(),
+		configStore,
+		registry,
+		healthCheckers,
+		middleware.NewLogger(logger),
+		middleware.NewMetrics(),
+		middleware.NewAuth(authConfig, serverConfig.Host),
 	)
-
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	server := &http.Server{
+		Addr:         serverConfig.Addr(),
+		Handler:      router,
+		ReadTimeout:  serverConfig.ReadTimeout,
+		WriteTimeout: serverConfig.WriteTimeout,
+		IdleTimeout:  serverConfig.IdleTimeout,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+	if serverConfig.TLSSelfSigned && serverConfig.TLSCertFile == "" {
+		cert, err := util.SelfSignedCertificate([]string{serverConfig.Host, "localhost", "127.0.0.1", "::1"})
+		if err != nil {
+			return fmt.Errorf("failed to generate self-signed certificate: %w", err)
+		}
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+		log.Println("Using a self-signed certificate; browsers will show a warning")
 	}
 
 	// 開発環境での情報を表示
 	if serverConfig.Env == "dev" {
-		printServerInfo(serverConfig.Host, serverConfig.Port)
+		printServerInfo(serverConfig.BaseURL())
+	}
+	if authConfig.Enabled(serverConfig.Host) {
+		printAuthInfo(serverConfig.BaseURL(), authConfig.LaunchToken)
 	}
 
-	err = http.ListenAndServe(fmt.Sprintf("%s:%d", serverConfig.Host, serverConfig.Port), router)
-	if err != nil {
-		panic(err)
+	serveErr := make(chan error, 1)
+	go func() {
+		if serverConfig.TLSEnabled() {
+			// 自己署名証明書の場合はTLSConfigの証明書が使われる
+			serveErr <- server.ListenAndServeTLS(serverConfig.TLSCertFile, serverConfig.TLSKeyFile)
+		} else {
+			serveErr <- server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		stopBackground()
+		wg.Wait()
+		return err
+	case <-ctx.Done():
+	}
+
+	// 新しい接続の受け付けを止め、保存中のリクエストなどが終わるのを待つ
+	log.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
+	defer cancel()
+	shutdownErr := server.Shutdown(shutdownCtx)
+	if shutdownErr != nil {
+		log.Printf("Failed to finish in-flight requests: %v", shutdownErr)
 	}
+
+	// インデックスの更新と設定の監視を止めてから未保存のインデックスを書き出す
+	stopBackground()
+	wg.Wait()
+	if err := localRepoProvider.FlushIndexes(); err != nil {
+		log.Printf("Failed to save index: %v", err)
+	}
+
+	log.Println("Server stopped")
+	return shutdownErr
 }
 
 // 開発環境での情報を表示する関数
-func printServerInfo(host string, port int) {
-	baseURL := fmt.Sprintf("http://%s:%d", host, port)
-	fmt.Println("API documentation available at:     ", baseURL+"/docs")
-	fmt.Println("YAML API specification available at:", baseURL+"/openapi.yaml")
+func printServerInfo(baseURL string) {
+	fmt.Println("Frontend available at:              ", baseURL+"/")
+	fmt.Println("API documentation available at:     ", baseURL+handler.APIPrefix+"/docs")
+	fmt.Println("YAML API specification available at:", baseURL+handler.APIPrefix+"/openapi.yaml")
+}
+
+// 認証が有効な場合にアクセス用のURLを表示する関数
+func printAuthInfo(baseURL string, token string) {
+	fmt.Println("Authentication is enabled. Open:     ", baseURL+"/?token="+token)
 }
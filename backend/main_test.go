@@ -0,0 +1,44 @@
+package main
+
+import (
+	"backend/config"
+	"backend/config/configtest"
+	"backend/domain"
+	"backend/handler"
+	"backend/infra/filesystem"
+	"backend/infra/provider/local"
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+)
+
+func TestNewProviderRegistry(t *testing.T) {
+	localRepoProvider, err := local.NewLocalProvider(filesystem.OS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	configProvider := configtest.NewProvider(&config.AppConfig{AppMode: config.CLI})
+	// 起動できないプラグインは登録せずに続ける
+	plugins := []config.Plugin{{Name: "missing", Command: "/nonexistent/repo-wise-plugin"}}
+
+	registry, healthCheckers, closeProviders, err := newProviderRegistry(context.Background(), filesystem.OS(), localRepoProvider, configProvider, plugins, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeProviders()
+
+	want := []domain.RepoKind{domain.LocalRepoKind, domain.ArchiveRepoKind, domain.S3RepoKind, domain.SFTPRepoKind}
+	if got := registry.Kinds(); !slices.Equal(got, want) {
+		t.Errorf("kinds = %v, want %v", got, want)
+	}
+	// CLIの--kindで指定した取得元もserveと同じプロバイダーで読める
+	for _, kind := range []string{"s3", "sftp"} {
+		if _, _, err := handler.FindProvider[handler.DocumentContentProvider](registry, kind, handler.CapabilityRead); err != nil {
+			t.Errorf("FindProvider(%s) = %v", kind, err)
+		}
+	}
+	if len(healthCheckers) != 3 {
+		t.Errorf("health checkers = %d, want 3", len(healthCheckers))
+	}
+}
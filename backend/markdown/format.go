@@ -0,0 +1,380 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	bulletPattern         = regexp.MustCompile(`^( *)[*+]([ \t])`)
+	tableDelimiterPattern = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	// 書式を変えてはいけないインラインの要素（コードスパン以外）
+	protectedInlinePattern = regexp.MustCompile(`<[^<>\s][^<>]*>|\]\([^)]*\)|https?://[^\s<>)\]]+`)
+	strongPattern          = regexp.MustCompile(`(^|[^\p{L}\p{N}_*\\])__([^\s_](?:[^_]*?[^\s_\\])?)__($|[^\p{L}\p{N}_*])`)
+	emphasisPattern        = regexp.MustCompile(`(^|[^\p{L}\p{N}_*\\])\*([^\s*_](?:[^*_]*?[^\s*_\\])?)\*($|[^\p{L}\p{N}_*])`)
+)
+
+// Format はMarkdownの書式を整える
+//   - 見出しはATX形式（# 見出し）に統一し、閉じの#を取り除く
+//   - 箇条書きの記号は-に、強調は_と**に統一する（記号を変えると隣のリストとつながる場合は変えない）
+//   - 表の列の幅を揃える
+//   - 行末の空白（改行を表す2つの空白を除く）、連続する空行、先頭と末尾の空行を取り除き、末尾を改行1つにする
+//
+// コードブロック（リストの項目の中や字下げされたものを含む）、HTMLブロック、フロントマターの中は変更しない
+func Format(content string) string {
+	newline := "\n"
+	if strings.Contains(content, "\r\n") {
+		newline = "\r\n"
+	}
+
+	lines := ScanLines(content)
+	headings := map[int]HeadingLine{}
+	for _, h := range ScanHeadings(lines) {
+		headings[h.Line] = h
+	}
+	keepBullets := adjacentBulletLists(lines)
+
+	var out []string
+	pendingBlank := false
+	emit := func(texts ...string) {
+		if pendingBlank && len(out) > 0 {
+			out = append(out, "")
+		}
+		pendingBlank = false
+		out = append(out, texts...)
+	}
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case line.Verbatim():
+			emit(line.Text)
+			i++
+		case strings.TrimSpace(line.Text) == "":
+			pendingBlank = true
+			i++
+		case headings[line.Number].Level > 0:
+			h := headings[line.Number]
+			// リストの項目の中の見出しは項目から出ないように字下げを残す
+			heading := strings.Repeat(" ", line.Indent) + strings.Repeat("#", h.Level)
+			if text := strings.TrimSpace(formatInline(h.Text)); text != "" {
+				heading += " " + text
+			}
+			emit(heading)
+			i += h.EndLine - h.Line + 1
+		default:
+			if n := tableLength(lines[i:]); n > 0 {
+				emit(formatTable(lines[i : i+n])...)
+				i += n
+				continue
+			}
+			// 段落の最後の行の改行は意味を持たないため残さない
+			next := i + 1
+			keepBreak := next < len(lines) && !lines[next].Verbatim() && strings.TrimSpace(lines[next].Text) != ""
+			emit(formatLine(line.Text, keepBreak, line.ListItem && !keepBullets[i]))
+			i++
+		}
+	}
+
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, newline) + newline
+}
+
+// formatLine は見出しと表以外の行の書式を整える
+// normalizeBullet はリストの項目の記号を-にするか
+func formatLine(text string, keepBreak bool, normalizeBullet bool) string {
+	if normalizeBullet {
+		text = bulletPattern.ReplaceAllString(text, "$1-$2")
+	}
+
+	trimmed := strings.TrimRight(text, " \t")
+	// 2つ以上の空白は改行（<br>）として扱われるため2つに揃えて残す
+	hardBreak := keepBreak && len(text)-len(trimmed) >= 2 && !strings.ContainsRune(text[len(trimmed):], '\t')
+	text = strings.TrimRight(formatInline(trimmed), " \t")
+	if hardBreak {
+		text += "  "
+	}
+	return text
+}
+
+// formatInline は強調の記号を統一し、エディターが出力する空白の文字参照を戻す
+// コードスパン、リンク先、URL、インラインのHTMLは変更しない
+func formatInline(text string) string {
+	placeholders := newPlaceholders(text)
+	protect := placeholders.protect
+
+	var b strings.Builder
+	last := 0
+	for _, r := range codeSpanRanges(text) {
+		b.WriteString(text[last:r[0]])
+		b.WriteString(protect(text[r[0]:r[1]]))
+		last = r[1]
+	}
+	b.WriteString(text[last:])
+	text = protectedInlinePattern.ReplaceAllStringFunc(b.String(), protect)
+
+	text = strings.NewReplacer("&#x20;", " ", "&#xa0;", "\u00a0", "&#xA0;", "\u00a0").Replace(text)
+	// 隣り合う強調は境界の文字を共有するため、変化しなくなるまで繰り返す
+	for _, rule := range []struct {
+		pattern *regexp.Regexp
+		repl    string
+	}{
+		{strongPattern, "$1**$2**$3"},
+		{emphasisPattern, "${1}_${2}_$3"},
+	} {
+		for {
+			replaced := rule.pattern.ReplaceAllString(text, rule.repl)
+			if replaced == text {
+				break
+			}
+			text = replaced
+		}
+	}
+	return placeholders.restore(text)
+}
+
+// placeholders は書式の変換中に保護する要素を印に置き換える
+// 印には元の文字列に含まれない制御文字の並びを使い、文書中の文字と取り違えないようにする
+type placeholders struct {
+	mark      string
+	protected []string
+}
+
+func newPlaceholders(text string) *placeholders {
+	mark := "\x00"
+	for strings.Contains(text, mark) {
+		mark += "\x00"
+	}
+	return &placeholders{mark: mark}
+}
+
+func (p *placeholders) protect(s string) string {
+	p.protected = append(p.protected, s)
+	return p.placeholder(len(p.protected) - 1)
+}
+
+func (p *placeholders) placeholder(i int) string {
+	return p.mark + strconv.Itoa(i) + p.mark
+}
+
+func (p *placeholders) restore(text string) string {
+	if len(p.protected) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, len(p.protected)*2)
+	for i, s := range p.protected {
+		pairs = append(pairs, p.placeholder(i), s)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// adjacentBulletLists は記号の違いだけで区切られた箇条書きのリストの項目の行の位置を返す
+// 記号を揃えると1つのリストになってしまうため、これらの記号は変えない
+func adjacentBulletLists(lines []Line) map[int]bool {
+	type bulletList struct {
+		marker byte
+		items  []int
+		keep   bool
+	}
+	keep := map[int]bool{}
+	// open はリストを含む項目の本文の開始位置ごとの、続いている箇条書きのリスト
+	open := map[int]*bulletList{}
+	closeFrom := func(indent int) {
+		for i := range open {
+			if i >= indent {
+				delete(open, i)
+			}
+		}
+	}
+	keepList := func(list *bulletList) {
+		list.keep = true
+		for _, i := range list.items {
+			keep[i] = true
+		}
+	}
+	for i, line := range lines {
+		if strings.TrimSpace(line.Text) == "" {
+			continue
+		}
+		if !line.ListItem {
+			closeFrom(line.Indent)
+			continue
+		}
+		closeFrom(line.Indent + 1)
+		_, rest := leadingIndent(line.Text)
+		marker := rest[0]
+		if marker != '-' && marker != '*' && marker != '+' {
+			delete(open, line.Indent)
+			continue
+		}
+
+		list := open[line.Indent]
+		if list == nil || list.marker != marker {
+			prev := list
+			list = &bulletList{marker: marker}
+			open[line.Indent] = list
+			if prev != nil {
+				keepList(prev)
+				keepList(list)
+			}
+		}
+		list.items = append(list.items, i)
+		keep[i] = list.keep
+	}
+	return keep
+}
+
+// tableLength はlinesの先頭から始まる表の行数を返す（表でない場合は0）
+func tableLength(lines []Line) int {
+	if len(lines) < 2 || !strings.Contains(lines[0].Text, "|") || lines[0].Verbatim() || lines[1].Verbatim() {
+		return 0
+	}
+	if strings.HasPrefix(lines[0].Text, " ") || !tableDelimiterPattern.MatchString(lines[1].Text) {
+		return 0
+	}
+	if len(splitTableRow(lines[0].Text)) != len(splitTableRow(lines[1].Text)) {
+		return 0
+	}
+	n := 2
+	for n < len(lines) && !lines[n].Verbatim() && strings.Contains(lines[n].Text, "|") && strings.TrimSpace(lines[n].Text) != "" {
+		n++
+	}
+	return n
+}
+
+type tableAlign int
+
+const (
+	alignNone tableAlign = iota
+	alignLeft
+	alignRight
+	alignCenter
+)
+
+// formatTable は表の列の幅を揃える
+// 見出しより多いセルがある行を含む場合は内容が失われないよう変更しない
+func formatTable(lines []Line) []string {
+	header := splitTableRow(lines[0].Text)
+	aligns := make([]tableAlign, len(header))
+	for i, cell := range splitTableRow(lines[1].Text) {
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			aligns[i] = alignCenter
+		case left:
+			aligns[i] = alignLeft
+		case right:
+			aligns[i] = alignRight
+		}
+	}
+
+	rows := [][]string{header}
+	for _, line := range lines[2:] {
+		cells := splitTableRow(line.Text)
+		if len(cells) > len(header) {
+			texts := make([]string, len(lines))
+			for i, l := range lines {
+				texts[i] = l.Text
+			}
+			return texts
+		}
+		rows = append(rows, append(cells, make([]string, len(header)-len(cells))...))
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i := range row {
+			row[i] = formatInline(row[i])
+			widths[i] = max(widths[i], 3, displayWidth(row[i]))
+		}
+	}
+
+	out := make([]string, 0, len(rows)+1)
+	for r, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = padCell(cell, widths[i], aligns[i])
+		}
+		out = append(out, "| "+strings.Join(cells, " | ")+" |")
+		if r == 0 {
+			delimiters := make([]string, len(widths))
+			for i, w := range widths {
+				delimiters[i] = delimiterCell(w, aligns[i])
+			}
+			out = append(out, "| "+strings.Join(delimiters, " | ")+" |")
+		}
+	}
+	return out
+}
+
+// splitTableRow は表の行をエスケープされていない|で区切ったセルに分ける
+func splitTableRow(text string) []string {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "|")
+	if strings.HasSuffix(text, "|") && !strings.HasSuffix(text, `\|`) {
+		text = text[:len(text)-1]
+	}
+
+	var cells []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '|':
+			cells = append(cells, strings.TrimSpace(text[start:i]))
+			start = i + 1
+		}
+	}
+	return append(cells, strings.TrimSpace(text[start:]))
+}
+
+func padCell(text string, width int, align tableAlign) string {
+	pad := width - displayWidth(text)
+	switch align {
+	case alignRight:
+		return strings.Repeat(" ", pad) + text
+	case alignCenter:
+		return strings.Repeat(" ", pad/2) + text + strings.Repeat(" ", pad-pad/2)
+	}
+	return text + strings.Repeat(" ", pad)
+}
+
+func delimiterCell(width int, align tableAlign) string {
+	switch align {
+	case alignLeft:
+		return ":" + strings.Repeat("-", width-1)
+	case alignRight:
+		return strings.Repeat("-", width-1) + ":"
+	case alignCenter:
+		return ":" + strings.Repeat("-", width-2) + ":"
+	}
+	return strings.Repeat("-", width)
+}
+
+// displayWidth は等幅フォントで表示したときの幅を返す（全角文字は2、結合文字は0）
+func displayWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case isWideRune(r):
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+func isWideRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		r >= 0x3000 && r <= 0x303F ||
+		r >= 0xFF01 && r <= 0xFF60 ||
+		r >= 0xFFE0 && r <= 0xFFE6 ||
+		r >= 0x1F300 && r <= 0x1FAFF
+}
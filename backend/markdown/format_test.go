@@ -0,0 +1,119 @@
+package markdown
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "setext heading becomes ATX",
+			content: "Title\n=====\n\nSection\n---\n",
+			want:    "# Title\n\n## Section\n",
+		},
+		{
+			name:    "closing hashes are removed",
+			content: "## Install ##\n",
+			want:    "## Install\n",
+		},
+		{
+			name:    "bullets and emphasis are normalised",
+			content: "* one *em*\n* two __strong__\n",
+			want:    "- one _em_\n- two **strong**\n",
+		},
+		{
+			name:    "code spans and URLs are kept",
+			content: "Use `*x*` and https://example.com/__a__*b*\n",
+			want:    "Use `*x*` and https://example.com/__a__*b*\n",
+		},
+		{
+			name:    "thematic break is kept",
+			content: "a\n\n* * *\n\nb\n",
+			want:    "a\n\n* * *\n\nb\n",
+		},
+		{
+			name:    "fenced code in a list item is kept",
+			content: "- item\n\n  ```\n  * x\n  __y__  \n  ```\n",
+			want:    "- item\n\n  ```\n  * x\n  __y__  \n  ```\n",
+		},
+		{
+			name:    "fenced code in a nested list item is kept",
+			content: "- item\n  1. nested\n\n     ~~~\n     # not a heading\n     ~~~\n",
+			want:    "- item\n  1. nested\n\n     ~~~\n     # not a heading\n     ~~~\n",
+		},
+		{
+			name:    "indented code is kept verbatim",
+			content: "text\n\n    * not a list  \n    __code__\n",
+			want:    "text\n\n    * not a list  \n    __code__\n",
+		},
+		{
+			name:    "private use characters round-trip",
+			content: "a \uE000 `b` \uE001 *c*\n",
+			want:    "a \uE000 `b` \uE001 _c_\n",
+		},
+		{
+			name:    "text that looks like a placeholder round-trips",
+			content: "a \x000\x00 `b`\n",
+			want:    "a \x000\x00 `b`\n",
+		},
+		{
+			name:    "table columns are aligned",
+			content: "|a|b|\n|:-|-:|\n|日本|x|\n",
+			want:    "| a    |   b |\n| :--- | --: |\n| 日本 |   x |\n",
+		},
+		{
+			name:    "blank lines are collapsed",
+			content: "a\n\n\n\nb\n\n\n",
+			want:    "a\n\nb\n",
+		},
+		{
+			name:    "heading in a list item stays in the item",
+			content: "- item\n\n  ## Sub in item ##\n\n  text\n",
+			want:    "- item\n\n  ## Sub in item\n\n  text\n",
+		},
+		{
+			name:    "setext heading in a list item stays in the item",
+			content: "1. item\n\n   Sub\n   ---\n",
+			want:    "1. item\n\n   ## Sub\n",
+		},
+		{
+			name:    "lists separated only by the bullet are kept apart",
+			content: "- a\n- b\n\n* c\n* d\n",
+			want:    "- a\n- b\n\n* c\n* d\n",
+		},
+		{
+			name:    "all adjacent lists keep their bullets",
+			content: "* a\n\n+ b\n+ c\n\n* d\n",
+			want:    "* a\n\n+ b\n+ c\n\n* d\n",
+		},
+		{
+			name:    "lists separated by a paragraph are normalised",
+			content: "* a\n\ntext\n\n+ b\n",
+			want:    "- a\n\ntext\n\n- b\n",
+		},
+		{
+			name:    "nested list with another bullet is normalised",
+			content: "* a\n  + b\n  + c\n* d\n",
+			want:    "- a\n  - b\n  - c\n- d\n",
+		},
+		{
+			name:    "blank lines in indented code are kept",
+			content: "    code\n\n\n    more code\n\n\ntext\n",
+			want:    "    code\n\n\n    more code\n\ntext\n",
+		},
+		{
+			name:    "hard break is kept",
+			content: "a  \nb\n",
+			want:    "a  \nb\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Format(tt.content); got != tt.want {
+				t.Errorf("Format(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
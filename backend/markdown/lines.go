@@ -0,0 +1,269 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// LineKind は行がどのブロックに含まれるか
+type LineKind int
+
+const (
+	// LineText は通常のMarkdownとして解釈する行
+	LineText LineKind = iota
+	// LineFrontmatter は先頭のYAML（---）またはTOML（+++）のフロントマター
+	LineFrontmatter
+	// LineFence はコードフェンスの開始・終了の行
+	LineFence
+	// LineCode はコードフェンスの中の行
+	LineCode
+	// LineHTML はHTMLブロックの行
+	LineHTML
+	// LineIndentedCode は4つ以上の空白で字下げされたコードブロックの行
+	LineIndentedCode
+)
+
+// Line は1行分の内容と種類
+type Line struct {
+	// Number は1始まりの行番号
+	Number int
+	Text   string
+	Kind   LineKind
+	// Indent は行を含むリストの項目の本文の開始位置（リストの外では0）
+	// リストの項目を始める行では、その項目を含む外側の項目の本文の開始位置
+	Indent int
+	// ListItem はリストの項目を始める行か
+	ListItem bool
+}
+
+// Verbatim は書き換えてはいけない行（コード、HTML、フロントマター）かを返す
+func (l Line) Verbatim() bool {
+	return l.Kind != LineText
+}
+
+var (
+	fencePattern       = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})(.*)$")
+	listContentPattern = regexp.MustCompile(`^([-+*]|\d{1,9}[.)])( *)(.*)$`)
+	htmlBlockPattern   = regexp.MustCompile(`^ {0,3}<(!--|[A-Za-z][A-Za-z0-9-]*[\s/>]|[A-Za-z][A-Za-z0-9-]*$|/[A-Za-z])`)
+)
+
+// SplitLines はcontentを行に分け、改行コード（\r\nと\n）を取り除く
+// 末尾の改行の後には行を作らない
+func SplitLines(content string) []string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		return nil
+	}
+	return strings.Split(content, "\n")
+}
+
+// ScanLines はcontentを行に分け、それぞれがフロントマター、コードブロック、HTMLブロックに含まれるかを判定する
+// リストの項目の中のコードフェンスや字下げされたコードブロックは項目の本文の開始位置を基準に判定する
+func ScanLines(content string) []Line {
+	texts := SplitLines(content)
+	lines := make([]Line, len(texts))
+	for i, text := range texts {
+		lines[i] = Line{Number: i + 1, Text: text, Kind: LineText}
+	}
+
+	i := scanFrontmatter(lines)
+	var (
+		fence string
+		// fenceIndent はフェンスを含むリストの項目の本文の開始位置
+		fenceIndent       int
+		inHTML, inComment bool
+		// htmlIndent はHTMLブロックを含むリストの項目の本文の開始位置
+		htmlIndent int
+		// items は開いているリストの項目の本文の開始位置
+		items []int
+		// paragraph は直前の行が段落の続きになりうる行か
+		paragraph bool
+		// codeBlanks は字下げされたコードブロックに続く空行で、次の行もコードであればコードに含める
+		codeBlanks     []int
+		inIndentedCode bool
+	)
+	for ; i < len(lines); i++ {
+		text := lines[i].Text
+		indent, rest := leadingIndent(text)
+
+		if fence != "" {
+			lines[i].Indent = fenceIndent
+			// 項目の本文より浅い行があれば項目と共にフェンスも終わる
+			if rest == "" || indent >= fenceIndent {
+				if rest != "" && indent-fenceIndent <= 3 && isClosingFence(rest, fence) {
+					lines[i].Kind = LineFence
+					fence = ""
+				} else {
+					lines[i].Kind = LineCode
+				}
+				continue
+			}
+			fence = ""
+		}
+
+		if inHTML {
+			lines[i].Indent = htmlIndent
+			if inComment {
+				lines[i].Kind = LineHTML
+				inComment = !strings.Contains(text, "-->")
+				inHTML = inComment
+				continue
+			}
+			if rest == "" {
+				inHTML = false
+				continue
+			}
+			lines[i].Kind = LineHTML
+			continue
+		}
+
+		if rest == "" {
+			if inIndentedCode {
+				codeBlanks = append(codeBlanks, i)
+			}
+			paragraph = false
+			continue
+		}
+		blanks := codeBlanks
+		codeBlanks, inIndentedCode = nil, false
+
+		// 字下げが浅い行でリストの項目が終わる（字下げせずに続けた段落の行を除く）
+		for len(items) > 0 && indent < items[len(items)-1] && (!paragraph || startsBlock(rest)) {
+			items = items[:len(items)-1]
+		}
+		base := 0
+		if len(items) > 0 {
+			base = items[len(items)-1]
+		}
+		lines[i].Indent = base
+
+		// 段落の続きでなければ字下げされたコードブロック
+		if indent-base >= 4 {
+			if !paragraph {
+				lines[i].Kind = LineIndentedCode
+				for _, j := range blanks {
+					lines[j].Kind = LineIndentedCode
+				}
+				inIndentedCode = true
+			}
+			continue
+		}
+
+		if f, ok := openingFence(rest); ok {
+			lines[i].Kind = LineFence
+			fence, fenceIndent = f, base
+			paragraph = false
+			continue
+		}
+
+		if htmlBlockPattern.MatchString(rest) {
+			lines[i].Kind = LineHTML
+			inHTML, htmlIndent = true, base
+			if strings.Contains(text, "<!--") {
+				inComment = !strings.Contains(text[strings.Index(text, "<!--"):], "-->")
+				inHTML = inComment
+			}
+			paragraph = false
+			continue
+		}
+
+		switch {
+		case thematicBreakPattern.MatchString(rest), atxHeadingPattern.MatchString(rest):
+			paragraph = false
+		case paragraph && setextPattern.MatchString(rest):
+			paragraph = false
+		case listItemPattern.MatchString(rest):
+			m := listContentPattern.FindStringSubmatch(rest)
+			spaces := len(m[2])
+			if m[3] == "" || spaces == 0 || spaces > 4 {
+				spaces = 1
+			}
+			items = append(items, indent+len(m[1])+spaces)
+			lines[i].ListItem = true
+			// 項目の最初の行からフェンスを始めることもできる
+			if f, ok := openingFence(m[3]); ok {
+				lines[i].Kind = LineFence
+				fence, fenceIndent = f, items[len(items)-1]
+				paragraph = false
+				continue
+			}
+			paragraph = m[3] != ""
+		default:
+			paragraph = true
+		}
+	}
+	return lines
+}
+
+// leadingIndent は行頭の空白の幅（タブは4の倍数の位置まで進める）と、空白を除いた残りを返す
+func leadingIndent(text string) (int, string) {
+	width := 0
+	for i, r := range text {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width, text[i:]
+		}
+	}
+	return width, ""
+}
+
+// openingFence はtextがコードフェンスの開始であればフェンスの文字列を返す
+func openingFence(text string) (string, bool) {
+	m := fencePattern.FindStringSubmatch(text)
+	if m == nil {
+		return "", false
+	}
+	// バッククォートのフェンスの情報文字列にはバッククォートを含められない
+	if m[1][0] == '`' && strings.Contains(m[2], "`") {
+		return "", false
+	}
+	return m[1], true
+}
+
+// startsBlock はtextが段落の続きではなく新しいブロックを始めるかを返す
+func startsBlock(text string) bool {
+	if _, ok := openingFence(text); ok {
+		return true
+	}
+	return listItemPattern.MatchString(text) ||
+		atxHeadingPattern.MatchString(text) ||
+		thematicBreakPattern.MatchString(text) ||
+		blockquotePattern.MatchString(text) ||
+		htmlBlockPattern.MatchString(text)
+}
+
+// scanFrontmatter は先頭のフロントマターの行に印を付け、その次の行の位置を返す
+// 閉じていない場合はフロントマターとして扱わない
+func scanFrontmatter(lines []Line) int {
+	if len(lines) == 0 {
+		return 0
+	}
+	delimiter := strings.TrimRight(lines[0].Text, " ")
+	if delimiter != "---" && delimiter != "+++" {
+		return 0
+	}
+	for i := 1; i < len(lines); i++ {
+		text := strings.TrimRight(lines[i].Text, " ")
+		if text == delimiter || delimiter == "---" && text == "..." {
+			for j := 0; j <= i; j++ {
+				lines[j].Kind = LineFrontmatter
+			}
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// isClosingFence はtextが開始したフェンスと同じ文字で同じ長さ以上のフェンスかを返す
+func isClosingFence(text string, fence string) bool {
+	m := fencePattern.FindStringSubmatch(text)
+	if m == nil || m[1][0] != fence[0] || len(m[1]) < len(fence) {
+		return false
+	}
+	return strings.TrimSpace(m[2]) == ""
+}
@@ -0,0 +1,442 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severity は診断結果の重大度
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// ParseSeverity は文字列を重大度に変換する
+func ParseSeverity(value string) (Severity, error) {
+	switch s := Severity(value); s {
+	case SeverityError, SeverityWarning, SeverityInfo:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown severity: %s", value)
+}
+
+// Diagnostic はリンターが検出した問題
+type Diagnostic struct {
+	Rule     string   `json:"rule" example:"no-trailing-spaces" doc:"ID of the rule that reported the problem"`
+	Severity Severity `json:"severity" enum:"error,warning,info" doc:"Severity of the problem"`
+	Line     int      `json:"line" example:"3" doc:"1-based line number"`
+	Column   int      `json:"column" example:"12" doc:"1-based column counted in Unicode code points"`
+	Message  string   `json:"message" example:"Trailing spaces" doc:"Description of the problem"`
+	Fixes    []Fix    `json:"fixes,omitempty" doc:"Edits that fix the problem, if it can be fixed automatically"`
+}
+
+// Fix は1行の中の範囲を置き換える修正
+// 列は1始まりでUnicodeのコードポイント単位、EndColumnは範囲に含まない
+type Fix struct {
+	Line      int    `json:"line" example:"3" doc:"1-based line number to edit"`
+	Column    int    `json:"column" example:"12" doc:"1-based first column to replace"`
+	EndColumn int    `json:"end_column" example:"14" doc:"1-based column just after the replaced range"`
+	Text      string `json:"text" example:"" doc:"Replacement text"`
+}
+
+// LintOptions はワークスペースごとのリンターの設定
+type LintOptions struct {
+	// Disabled は無効にする規則のID
+	Disabled []string
+	// Severity は規則ごとの重大度の上書き
+	Severity map[string]Severity
+	// LineLength はline-lengthの1行の最大文字数（0の場合は既定値）
+	LineLength int
+}
+
+// DefaultLineLength はline-lengthの既定の最大文字数
+const DefaultLineLength = 120
+
+type lintRule struct {
+	id       string
+	severity Severity
+	check    func(doc *lintDocument, report reportFunc)
+}
+
+type reportFunc func(line Line, column int, message string, fixes ...Fix)
+
+// lintRules は全ての規則（IDの順序は出力の順序にも使う）
+// 文書の構造を壊す規則はerrorとし、--strictなしのlintでもCIを失敗させる
+var lintRules = []lintRule{
+	{"heading-increment", SeverityError, checkHeadingIncrement},
+	{"no-duplicate-heading", SeverityWarning, checkDuplicateHeading},
+	{"no-trailing-spaces", SeverityWarning, checkTrailingSpaces},
+	{"list-indent", SeverityWarning, checkListIndent},
+	{"no-bare-urls", SeverityInfo, checkBareURLs},
+	{"image-alt-text", SeverityError, checkImageAltText},
+	{"line-length", SeverityInfo, checkLineLength},
+}
+
+// LintRuleIDs は全ての規則のIDを返す
+func LintRuleIDs() []string {
+	ids := make([]string, len(lintRules))
+	for i, r := range lintRules {
+		ids[i] = r.id
+	}
+	return ids
+}
+
+// IsLintRule はidが存在する規則かを返す
+func IsLintRule(id string) bool {
+	return slices.Contains(LintRuleIDs(), id)
+}
+
+type lintDocument struct {
+	lines      []Line
+	headings   []HeadingLine
+	lineLength int
+}
+
+// Lint はcontentを全ての有効な規則で検査し、行と列の順に診断結果を返す
+func Lint(content string, opts LintOptions) []Diagnostic {
+	lines := ScanLines(content)
+	doc := &lintDocument{
+		lines:      lines,
+		headings:   ScanHeadings(lines),
+		lineLength: opts.LineLength,
+	}
+	if doc.lineLength <= 0 {
+		doc.lineLength = DefaultLineLength
+	}
+
+	diagnostics := []Diagnostic{}
+	for _, rule := range lintRules {
+		if slices.Contains(opts.Disabled, rule.id) {
+			continue
+		}
+		severity := rule.severity
+		if s, ok := opts.Severity[rule.id]; ok {
+			severity = s
+		}
+		rule.check(doc, func(line Line, column int, message string, fixes ...Fix) {
+			diagnostics = append(diagnostics, Diagnostic{
+				Rule:     rule.id,
+				Severity: severity,
+				Line:     line.Number,
+				Column:   column,
+				Message:  message,
+				Fixes:    fixes,
+			})
+		})
+	}
+
+	slices.SortStableFunc(diagnostics, func(a, b Diagnostic) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return diagnostics
+}
+
+// ApplyFixes はdiagnosticsの修正をcontentに適用する
+// 同じ行で範囲が重なる修正は後から見つかった方を適用しない
+func ApplyFixes(content string, diagnostics []Diagnostic) string {
+	fixes := map[int][]Fix{}
+	for _, d := range diagnostics {
+		for _, fix := range d.Fixes {
+			fixes[fix.Line] = append(fixes[fix.Line], fix)
+		}
+	}
+	if len(fixes) == 0 {
+		return content
+	}
+
+	newline := "\n"
+	if strings.Contains(content, "\r\n") {
+		newline = "\r\n"
+	}
+	lines := SplitLines(content)
+	for number, lineFixes := range fixes {
+		if number < 1 || number > len(lines) {
+			continue
+		}
+		// 後ろから適用して前の修正の列がずれないようにする
+		slices.SortStableFunc(lineFixes, func(a, b Fix) int { return b.Column - a.Column })
+		runes := []rune(lines[number-1])
+		limit := len(runes) + 1
+		for _, fix := range lineFixes {
+			if fix.Column < 1 || fix.EndColumn < fix.Column || fix.EndColumn > limit {
+				continue
+			}
+			runes = slices.Concat(runes[:fix.Column-1], []rune(fix.Text), runes[fix.EndColumn-1:])
+			limit = fix.Column
+		}
+		lines[number-1] = string(runes)
+	}
+
+	fixed := strings.Join(lines, newline)
+	if strings.HasSuffix(content, "\n") {
+		fixed += newline
+	}
+	return fixed
+}
+
+// column はtextのバイト位置offsetを1始まりのコードポイント単位の列に変換する
+func column(text string, offset int) int {
+	return utf8.RuneCountInString(text[:offset]) + 1
+}
+
+// checkHeadingIncrement は直前の見出しより2つ以上深い見出しを報告する
+// 修正では元のレベルが同じ兄弟の見出しを同じレベルにそろえ、後続の見出しの修正も最初の問題にまとめる
+func checkHeadingIncrement(doc *lintDocument, report reportFunc) {
+	type reported struct {
+		line    Line
+		message string
+		fixes   []Fix
+	}
+	var problems []*reported
+	var current *reported
+
+	levels := fixedHeadingLevels(doc.headings)
+	prev := 0
+	for i, h := range doc.headings {
+		line := doc.lines[h.Line-1]
+		if prev > 0 && h.Level > prev+1 {
+			current = &reported{
+				line:    line,
+				message: fmt.Sprintf("Heading level should increment by one level at a time (expected h%d, found h%d)", prev+1, h.Level),
+			}
+			problems = append(problems, current)
+		}
+		prev = h.Level
+
+		if levels[i] == h.Level || h.Setext || current == nil {
+			continue
+		}
+		start := strings.Index(line.Text, "#")
+		current.fixes = append(current.fixes, Fix{
+			Line:      line.Number,
+			Column:    column(line.Text, start),
+			EndColumn: column(line.Text, start+h.Level),
+			Text:      strings.Repeat("#", levels[i]),
+		})
+	}
+	for _, p := range problems {
+		report(p.line, 1, p.message, p.fixes...)
+	}
+}
+
+// fixedHeadingLevels は見出しの階層を保ったまま1つずつ深くなるようにしたレベルを返す
+// 元のレベルが同じ兄弟の見出しは同じレベルになる
+func fixedHeadingLevels(headings []HeadingLine) []int {
+	type open struct{ level, fixed int }
+	var stack []open
+	levels := make([]int, len(headings))
+	for i, h := range headings {
+		for len(stack) > 0 && stack[len(stack)-1].level > h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		switch {
+		case len(stack) > 0 && stack[len(stack)-1].level == h.Level:
+			levels[i] = stack[len(stack)-1].fixed
+			stack = stack[:len(stack)-1]
+		case len(stack) > 0:
+			levels[i] = stack[len(stack)-1].fixed + 1
+		default:
+			levels[i] = h.Level
+		}
+		stack = append(stack, open{h.Level, levels[i]})
+	}
+	return levels
+}
+
+func checkDuplicateHeading(doc *lintDocument, report reportFunc) {
+	seen := map[string]int{}
+	for _, h := range doc.headings {
+		text := PlainText(h.Text)
+		if first, ok := seen[text]; ok {
+			report(doc.lines[h.Line-1], 1, fmt.Sprintf("Duplicate heading %q (first used on line %d)", text, first))
+			continue
+		}
+		seen[text] = h.Line
+	}
+}
+
+func checkTrailingSpaces(doc *lintDocument, report reportFunc) {
+	for _, line := range doc.lines {
+		if line.Verbatim() {
+			continue
+		}
+		trimmed := strings.TrimRight(line.Text, " \t")
+		trailing := line.Text[len(trimmed):]
+		// 2つの空白は改行（<br>）として扱われるため許可する
+		if trailing == "" || trailing == "  " && trimmed != "" {
+			continue
+		}
+		start := column(line.Text, len(trimmed))
+		report(line, start, "Trailing spaces", Fix{
+			Line:      line.Number,
+			Column:    start,
+			EndColumn: start + utf8.RuneCountInString(trailing),
+			Text:      "",
+		})
+	}
+}
+
+var listMarkerPattern = regexp.MustCompile(`^( *)([-+*]|\d{1,9}[.)])( +|$)`)
+
+// checkListIndent は入れ子のリストの項目が親の項目の本文と同じ位置から始まっているかを検査する
+func checkListIndent(doc *lintDocument, report reportFunc) {
+	// 開いているリストの項目の本文の開始位置
+	var parents []int
+	for _, line := range doc.lines {
+		if line.Verbatim() {
+			continue
+		}
+		if strings.TrimSpace(line.Text) == "" {
+			continue
+		}
+
+		m := listMarkerPattern.FindStringSubmatch(line.Text)
+		if m == nil {
+			// インデントされていない段落でリストが終わる
+			if !strings.HasPrefix(line.Text, " ") && !strings.HasPrefix(line.Text, "\t") {
+				parents = parents[:0]
+			}
+			continue
+		}
+
+		indent := len(m[1])
+		for len(parents) > 0 && parents[len(parents)-1] > indent {
+			parents = parents[:len(parents)-1]
+		}
+		expected := 0
+		if len(parents) > 0 {
+			expected = parents[len(parents)-1]
+		}
+		if indent != expected {
+			report(line, 1, fmt.Sprintf("List item indentation should be %d spaces (found %d)", expected, indent), Fix{
+				Line:      line.Number,
+				Column:    1,
+				EndColumn: indent + 1,
+				Text:      strings.Repeat(" ", expected),
+			})
+			indent = expected
+		}
+
+		// 本文の開始位置（マーカーの後の空白が5つ以上の場合は1つとみなす）
+		spaces := len(m[3])
+		if spaces == 0 || spaces > 4 {
+			spaces = 1
+		}
+		parents = append(parents, indent+len(m[2])+spaces)
+	}
+}
+
+var (
+	bareURLPattern     = regexp.MustCompile(`https?://[^\s<>\x60]+`)
+	linkDefPattern     = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:`)
+	urlTrailingPattern = regexp.MustCompile(`[.,;:!?'"*_)\]]+$`)
+)
+
+func checkBareURLs(doc *lintDocument, report reportFunc) {
+	for _, line := range doc.lines {
+		if line.Verbatim() || linkDefPattern.MatchString(line.Text) {
+			continue
+		}
+		code := codeSpanRanges(line.Text)
+		for _, loc := range bareURLPattern.FindAllStringIndex(line.Text, -1) {
+			start, end := loc[0], loc[1]
+			end -= len(urlTrailingPattern.FindString(line.Text[start:end]))
+			if inRanges(code, start) || start > 0 && strings.ContainsAny(line.Text[start-1:start], `<([="'`) {
+				continue
+			}
+			url := line.Text[start:end]
+			report(line, column(line.Text, start), fmt.Sprintf("Bare URL %s; wrap it in <> or use a link", url), Fix{
+				Line:      line.Number,
+				Column:    column(line.Text, start),
+				EndColumn: column(line.Text, end),
+				Text:      "<" + url + ">",
+			})
+		}
+	}
+}
+
+var (
+	emptyAltPattern = regexp.MustCompile(`!\[\s*\]\(`)
+	imgTagPattern   = regexp.MustCompile(`(?i)<img\b[^>]*>`)
+	altAttrPattern  = regexp.MustCompile(`(?i)\balt\s*=`)
+)
+
+func checkImageAltText(doc *lintDocument, report reportFunc) {
+	for _, line := range doc.lines {
+		if line.Kind == LineText {
+			code := codeSpanRanges(line.Text)
+			for _, loc := range emptyAltPattern.FindAllStringIndex(line.Text, -1) {
+				if !inRanges(code, loc[0]) {
+					report(line, column(line.Text, loc[0]), "Image should have alternate text")
+				}
+			}
+		}
+		// HTMLブロックの中のimgタグも検査する
+		if line.Kind == LineText || line.Kind == LineHTML {
+			for _, loc := range imgTagPattern.FindAllStringIndex(line.Text, -1) {
+				if !altAttrPattern.MatchString(line.Text[loc[0]:loc[1]]) {
+					report(line, column(line.Text, loc[0]), "Image should have alternate text")
+				}
+			}
+		}
+	}
+}
+
+func checkLineLength(doc *lintDocument, report reportFunc) {
+	for _, line := range doc.lines {
+		if line.Verbatim() || utf8.RuneCountInString(line.Text) <= doc.lineLength {
+			continue
+		}
+		// 表や長いURLなど、折り返せない行は対象外
+		if strings.HasPrefix(strings.TrimSpace(line.Text), "|") || linkDefPattern.MatchString(line.Text) {
+			continue
+		}
+		rest := string([]rune(line.Text)[doc.lineLength:])
+		if !strings.ContainsAny(rest, " \t") && strings.Contains(line.Text, "://") {
+			continue
+		}
+		report(line, doc.lineLength+1, fmt.Sprintf("Line is longer than %d characters (%d)", doc.lineLength, utf8.RuneCountInString(line.Text)))
+	}
+}
+
+// codeSpanRanges はtext内のコードスパンのバイト範囲を返す
+func codeSpanRanges(text string) [][2]int {
+	var ranges [][2]int
+	for i := 0; i < len(text); {
+		if text[i] != '`' {
+			i++
+			continue
+		}
+		n := 1
+		for i+n < len(text) && text[i+n] == '`' {
+			n++
+		}
+		closing := strings.Index(text[i+n:], strings.Repeat("`", n))
+		if closing < 0 {
+			i += n
+			continue
+		}
+		end := i + n + closing + n
+		ranges = append(ranges, [2]int{i, end})
+		i = end
+	}
+	return ranges
+}
+
+func inRanges(ranges [][2]int, offset int) bool {
+	for _, r := range ranges {
+		if offset >= r[0] && offset < r[1] {
+			return true
+		}
+	}
+	return false
+}
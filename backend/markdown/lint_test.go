@@ -0,0 +1,148 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ruleLines はruleの診断結果の行番号を返す
+func ruleLines(diagnostics []Diagnostic, rule string) []int {
+	var lines []int
+	for _, d := range diagnostics {
+		if d.Rule == rule {
+			lines = append(lines, d.Line)
+		}
+	}
+	return lines
+}
+
+func TestHeadingIncrement(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		// reported は問題として報告される行
+		reported []int
+		fixed    string
+	}{
+		{
+			name:     "incrementing headings",
+			content:  "# A\n\n## B\n\n### C\n\n# D\n",
+			reported: nil,
+			fixed:    "# A\n\n## B\n\n### C\n\n# D\n",
+		},
+		{
+			name:     "first heading may start at any level",
+			content:  "### A\n\n#### B\n",
+			reported: nil,
+			fixed:    "### A\n\n#### B\n",
+		},
+		{
+			name:     "siblings after a jump",
+			content:  "# A\n\n#### B\n\n#### C\n",
+			reported: []int{3},
+			fixed:    "# A\n\n## B\n\n## C\n",
+		},
+		{
+			name:     "children of a fixed heading",
+			content:  "# A\n\n### B\n\n#### C\n\n### D\n",
+			reported: []int{3},
+			fixed:    "# A\n\n## B\n\n### C\n\n## D\n",
+		},
+		{
+			name:     "deep jump then return",
+			content:  "# A\n\n#### B\n\n## C\n\n#### D\n",
+			reported: []int{3, 7},
+			fixed:    "# A\n\n## B\n\n## C\n\n### D\n",
+		},
+		{
+			name:     "return to a shallower level before jumping again",
+			content:  "# A\n\n### B\n\n#### C\n\n## D\n\n#### E\n",
+			reported: []int{3, 9},
+			fixed:    "# A\n\n## B\n\n### C\n\n## D\n\n### E\n",
+		},
+		{
+			name:     "setext heading after a jump",
+			content:  "# A\n\n### B\n\nC\n-\n",
+			reported: []int{3},
+			fixed:    "# A\n\n## B\n\nC\n-\n",
+		},
+		{
+			name:     "closing sequence is kept",
+			content:  "# A #\n\n### B ###\n",
+			reported: []int{3},
+			fixed:    "# A #\n\n## B ###\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics := Lint(tt.content, LintOptions{})
+			if got := ruleLines(diagnostics, "heading-increment"); fmt.Sprint(got) != fmt.Sprint(tt.reported) {
+				t.Errorf("reported lines = %v, want %v", got, tt.reported)
+			}
+			fixed := ApplyFixes(tt.content, diagnostics)
+			if fixed != tt.fixed {
+				t.Errorf("fixed =\n%s\nwant:\n%s", fixed, tt.fixed)
+			}
+			// 修正後には問題が残らない
+			if remaining := ruleLines(Lint(fixed, LintOptions{}), "heading-increment"); len(remaining) > 0 {
+				t.Errorf("problems remain after fixing on lines %v", remaining)
+			}
+		})
+	}
+}
+
+func TestLintFixes(t *testing.T) {
+	content := "# Title  \n\nSee https://example.com.\n\n - item\n   - nested\n\n![](image.png)\n"
+	diagnostics := Lint(content, LintOptions{})
+
+	var rules []string
+	for _, d := range diagnostics {
+		rules = append(rules, fmt.Sprintf("%d:%s", d.Line, d.Rule))
+	}
+	want := []string{"3:no-bare-urls", "5:list-indent", "6:list-indent", "8:image-alt-text"}
+	if fmt.Sprint(rules) != fmt.Sprint(want) {
+		t.Errorf("diagnostics = %v, want %v", rules, want)
+	}
+
+	fixed := ApplyFixes(content, diagnostics)
+	if want := "# Title  \n\nSee <https://example.com>.\n\n- item\n  - nested\n\n![](image.png)\n"; fixed != want {
+		t.Errorf("fixed =\n%s\nwant:\n%s", fixed, want)
+	}
+}
+
+func TestLintOptions(t *testing.T) {
+	content := "# A\n\n### B\n" + strings.Repeat("x ", 50) + "\n"
+	diagnostics := Lint(content, LintOptions{
+		Disabled:   []string{"heading-increment"},
+		Severity:   map[string]Severity{"line-length": SeverityError},
+		LineLength: 80,
+	})
+	if len(diagnostics) != 2 {
+		t.Fatalf("diagnostics = %+v", diagnostics)
+	}
+	for _, d := range diagnostics {
+		if d.Rule == "heading-increment" {
+			t.Errorf("disabled rule was reported: %+v", d)
+		}
+		if d.Rule == "line-length" && d.Severity != SeverityError {
+			t.Errorf("severity = %s, want error", d.Severity)
+		}
+	}
+}
+
+func TestLintDefaultSeverity(t *testing.T) {
+	content := "# A\n\n### B\n\n![](image.png) https://example.com\n"
+	got := map[string]Severity{}
+	for _, d := range Lint(content, LintOptions{}) {
+		got[d.Rule] = d.Severity
+	}
+	want := map[string]Severity{
+		"heading-increment": SeverityError,
+		"image-alt-text":    SeverityError,
+		"no-bare-urls":      SeverityInfo,
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("severities = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Heading はドキュメントの見出し
+type Heading struct {
+	Level int    `json:"level" example:"2" doc:"Heading level (1-6)"`
+	Text  string `json:"text" example:"Getting started" doc:"Heading text without Markdown syntax"`
+	// Line は1始まりの行番号（setext見出しは本文の最初の行）
+	Line     int       `json:"line" example:"12" doc:"1-based line number of the heading"`
+	Slug     string    `json:"slug" example:"getting-started" doc:"GitHub compatible anchor of the heading"`
+	Children []Heading `json:"children" doc:"Headings nested under this heading"`
+}
+
+var (
+	atxHeadingPattern    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?[ \t]*$`)
+	atxClosingPattern    = regexp.MustCompile(`(?:^|[ \t]+)#+$`)
+	setextPattern        = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	thematicBreakPattern = regexp.MustCompile(`^ {0,3}((\*[ \t]*){3,}|(-[ \t]*){3,}|(_[ \t]*){3,})$`)
+	listItemPattern      = regexp.MustCompile(`^ {0,3}([-+*]|\d{1,9}[.)])([ \t]|$)`)
+	blockquotePattern    = regexp.MustCompile(`^ {0,3}>`)
+)
+
+// HeadingLine はドキュメント内の1つの見出しの位置
+type HeadingLine struct {
+	Level int
+	// Text は見出しのMarkdownのままの文字列
+	Text string
+	// Line は見出しの最初の行、EndLineは最後の行（setext見出しの下線）
+	Line    int
+	EndLine int
+	Setext  bool
+}
+
+// ScanHeadings はコードフェンスやHTMLブロック、フロントマターの中を除いた見出しを出現順に返す
+func ScanHeadings(lines []Line) []HeadingLine {
+	var headings []HeadingLine
+	// 段落の開始行（setext見出しの本文になりうる行）
+	paragraph := -1
+	for i, line := range lines {
+		if line.Verbatim() {
+			paragraph = -1
+			continue
+		}
+		text := line.Text
+
+		if m := atxHeadingPattern.FindStringSubmatch(text); m != nil {
+			content := atxClosingPattern.ReplaceAllString(m[2], "")
+			headings = append(headings, HeadingLine{
+				Level:   len(m[1]),
+				Text:    strings.TrimSpace(content),
+				Line:    line.Number,
+				EndLine: line.Number,
+			})
+			paragraph = -1
+			continue
+		}
+
+		if m := setextPattern.FindStringSubmatch(text); m != nil && paragraph >= 0 {
+			var parts []string
+			for _, l := range lines[paragraph:i] {
+				parts = append(parts, strings.TrimSpace(l.Text))
+			}
+			level := 1
+			if m[1][0] == '-' {
+				level = 2
+			}
+			headings = append(headings, HeadingLine{
+				Level:   level,
+				Text:    strings.Join(parts, " "),
+				Line:    lines[paragraph].Number,
+				EndLine: line.Number,
+				Setext:  true,
+			})
+			paragraph = -1
+			continue
+		}
+
+		switch {
+		case strings.TrimSpace(text) == "",
+			thematicBreakPattern.MatchString(text),
+			listItemPattern.MatchString(text),
+			blockquotePattern.MatchString(text):
+			paragraph = -1
+		case paragraph < 0 && !strings.HasPrefix(text, "    ") && !strings.HasPrefix(text, "\t"):
+			paragraph = i
+		}
+	}
+	return headings
+}
+
+// Outline はcontentの見出しを階層構造にして返す
+// 見出しはそれより前にある、レベルが小さい直近の見出しの子になる
+func Outline(content string) []Heading {
+	slugger := NewSlugger()
+	root := &Heading{Children: []Heading{}}
+	// stackは現在の見出しの祖先（rootを含む）
+	stack := []*Heading{root}
+	for _, h := range ScanHeadings(ScanLines(content)) {
+		text := PlainText(h.Text)
+		heading := Heading{
+			Level:    h.Level,
+			Text:     text,
+			Line:     h.Line,
+			Slug:     slugger.Slug(text),
+			Children: []Heading{},
+		}
+		for len(stack) > 1 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		parent.Children = append(parent.Children, heading)
+		stack = append(stack, &parent.Children[len(parent.Children)-1])
+	}
+	return root.Children
+}
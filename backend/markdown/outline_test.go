@@ -0,0 +1,108 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// formatOutline は見出しの階層を1行に1つずつ字下げして表す
+func formatOutline(headings []Heading, depth int, b *strings.Builder) {
+	for _, h := range headings {
+		fmt.Fprintf(b, "%s%d %s #%s L%d\n", strings.Repeat("  ", depth), h.Level, h.Text, h.Slug, h.Line)
+		formatOutline(h.Children, depth+1, b)
+	}
+}
+
+func TestOutline(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "nested levels",
+			content: "# Title\n\n## Install\n\n### Linux\n\n## Usage\n",
+			want: "1 Title #title L1\n" +
+				"  2 Install #install L3\n" +
+				"    3 Linux #linux L5\n" +
+				"  2 Usage #usage L7\n",
+		},
+		{
+			name:    "skipped level becomes child of the nearest higher heading",
+			content: "## Intro\n\n#### Detail\n\n# Top\n",
+			want: "2 Intro #intro L1\n" +
+				"  4 Detail #detail L3\n" +
+				"1 Top #top L5\n",
+		},
+		{
+			name:    "headings in fenced code are ignored",
+			content: "# Title\n\n```sh\n# comment\n## not a heading\n```\n\n~~~\n# also code\n~~~\n\n## After\n",
+			want: "1 Title #title L1\n" +
+				"  2 After #after L12\n",
+		},
+		{
+			name:    "unclosed fence hides the rest of the document",
+			content: "# Title\n\n````\n# code\n```\n## still code\n",
+			want:    "1 Title #title L1\n",
+		},
+		{
+			name:    "setext headings",
+			content: "Title\n=====\n\nSection\nwith two lines\n---\n\n- item\n---\n",
+			want: "1 Title #title L1\n" +
+				"  2 Section with two lines #section-with-two-lines L4\n",
+		},
+		{
+			name:    "thematic break after a blank line is not a heading",
+			content: "# Title\n\ntext\n\n---\n",
+			want:    "1 Title #title L1\n",
+		},
+		{
+			name:    "duplicate slugs get a suffix",
+			content: "# FAQ\n## Usage\n## Usage\n## Usage\n# Usage-1\n",
+			want: "1 FAQ #faq L1\n" +
+				"  2 Usage #usage L2\n" +
+				"  2 Usage #usage-1 L3\n" +
+				"  2 Usage #usage-2 L4\n" +
+				"1 Usage-1 #usage-1-1 L5\n",
+		},
+		{
+			name:    "CJK headings",
+			content: "# はじめに\n\n## インストール方法（Linux）\n\n## 使い方 & 設定\n",
+			want: "1 はじめに #はじめに L1\n" +
+				"  2 インストール方法（Linux） #インストール方法linux L3\n" +
+				"  2 使い方 & 設定 #使い方--設定 L5\n",
+		},
+		{
+			name:    "inline markup is removed from the text",
+			content: "# The `go` [tool](https://go.dev) is **fast** ##\n",
+			want:    "1 The go tool is fast #the-go-tool-is-fast L1\n",
+		},
+		{
+			name:    "fences nested in list items",
+			content: "# Title\n\n- step\n\n  ```sh\n  # comment\n  ```\n\n1. nested\n   - item\n     ```\n     # comment\n     ```\n\n## After\n",
+			want: "1 Title #title L1\n" +
+				"  2 After #after L15\n",
+		},
+		{
+			name:    "indented code is not a heading",
+			content: "# Title\n\n    # comment\n    code\n    ---\n\n## After\n",
+			want: "1 Title #title L1\n" +
+				"  2 After #after L7\n",
+		},
+		{
+			name:    "front matter and HTML blocks are ignored",
+			content: "---\ntitle: x\n# not heading\n---\n<details>\n# hidden\n</details>\n\n# Title\n",
+			want:    "1 Title #title L9\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			formatOutline(Outline(tt.content), 0, &b)
+			if got := b.String(); got != tt.want {
+				t.Errorf("Outline() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	imagePattern   = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	linkPattern    = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	refLinkPattern = regexp.MustCompile(`\[([^\]]*)\]\[[^\]]*\]`)
+	htmlTagPattern = regexp.MustCompile(`</?[A-Za-z][^>]*>`)
+)
+
+// PlainText は見出しなどのインラインのMarkdownを表示される文字列に変換する
+func PlainText(inline string) string {
+	text := imagePattern.ReplaceAllString(inline, "$1")
+	text = linkPattern.ReplaceAllString(text, "$1")
+	text = refLinkPattern.ReplaceAllString(text, "$1")
+	text = htmlTagPattern.ReplaceAllString(text, "")
+
+	runes := []rune(text)
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		n := runLength(runes, i)
+		switch r {
+		case '`':
+			// 同じ長さのバッククォートで閉じられたコードスパンの中はそのまま残す
+			if end := findRun(runes, i+n, '`', n); end >= 0 {
+				b.WriteString(strings.TrimSpace(string(runes[i+n : end])))
+				i = end + n
+				continue
+			}
+		case '*', '_':
+			if isEmphasisDelimiter(runes, i, n) {
+				i += n
+				continue
+			}
+		}
+		b.WriteString(string(runes[i : i+n]))
+		i += n
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// runLength はrunes[i]と同じ文字が続く長さを返す
+func runLength(runes []rune, i int) int {
+	n := 1
+	for i+n < len(runes) && runes[i+n] == runes[i] {
+		n++
+	}
+	return n
+}
+
+// findRun はfrom以降でrがちょうどn個続く位置を返す（無い場合は-1）
+func findRun(runes []rune, from int, r rune, n int) int {
+	for i := from; i < len(runes); {
+		if runes[i] != r {
+			i++
+			continue
+		}
+		length := runLength(runes, i)
+		if length == n {
+			return i
+		}
+		i += length
+	}
+	return -1
+}
+
+// isEmphasisDelimiter は*や_の連続が強調の記号として使われているかを返す
+// 両側が空白の場合や、単語の途中の_（snake_caseなど）は文字として扱う
+func isEmphasisDelimiter(runes []rune, i int, n int) bool {
+	prevSpace := i == 0 || unicode.IsSpace(runes[i-1])
+	nextSpace := i+n >= len(runes) || unicode.IsSpace(runes[i+n])
+	if prevSpace && nextSpace {
+		return false
+	}
+	if runes[i] == '_' {
+		prevWord := i > 0 && isWordRune(runes[i-1])
+		nextWord := i+n < len(runes) && isWordRune(runes[i+n])
+		return !(prevWord && nextWord)
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// Slugger はGitHubと同じ規則で見出しのアンカーを生成する
+// 同じ文書内で重複した場合は -1, -2 のように連番を付ける
+type Slugger struct {
+	seen map[string]int
+}
+
+func NewSlugger() *Slugger {
+	return &Slugger{seen: map[string]int{}}
+}
+
+// Slug はtext（表示される文字列）から重複しないアンカーを返す
+func (s *Slugger) Slug(text string) string {
+	base := Slug(text)
+	n, ok := s.seen[base]
+	if !ok {
+		s.seen[base] = 0
+		return base
+	}
+	// 連番を付けたアンカーが既に見出しとして存在する場合は次の番号にする
+	for {
+		n++
+		slug := base + "-" + strconv.Itoa(n)
+		if _, ok := s.seen[slug]; !ok {
+			s.seen[base] = n
+			s.seen[slug] = 0
+			return slug
+		}
+	}
+}
+
+// Slug は小文字にし、文字・数字・空白・ハイフン・アンダースコア以外を除いて空白をハイフンにする
+func Slug(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
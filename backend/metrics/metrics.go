@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "repo_wise"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of API requests by operation and status code.",
+	}, []string{"operation", "method", "status"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of API requests by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	walkFilesScanned = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "walker_files_scanned_total",
+		Help:      "Number of files visited while walking directories for documents.",
+	})
+
+	walkDirsSkipped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "walker_dirs_skipped_total",
+		Help:      "Number of directories skipped by exclude rules while walking.",
+	})
+
+	walkDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "walker_duration_seconds",
+		Help:      "Duration of directory walks by source (request or index).",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"source"})
+
+	providerErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provider_errors_total",
+		Help:      "Number of errors returned by providers by kind and operation.",
+	}, []string{"kind", "operation"})
+
+	configReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_reloads_total",
+		Help:      "Number of configuration reloads by result.",
+	}, []string{"result"})
+
+	staticRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "static_requests_total",
+		Help:      "Number of frontend requests by type (asset, index, not_found).",
+	}, []string{"type"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestsTotal,
+		requestDuration,
+		walkFilesScanned,
+		walkDirsSkipped,
+		walkDuration,
+		providerErrors,
+		configReloads,
+		staticRequests,
+	)
+}
+
+// Handler は /metrics で公開するハンドラーを返す
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+func ObserveRequest(operation string, method string, status int, duration time.Duration) {
+	requestsTotal.WithLabelValues(operation, method, strconv.Itoa(status)).Inc()
+	requestDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+// WalkStats はディレクトリ走査1回分の統計
+type WalkStats struct {
+	FilesScanned int
+	DirsSkipped  int
+}
+
+// ObserveWalk はsource（"request" または "index"）ごとの走査の統計を記録する
+func ObserveWalk(source string, stats WalkStats, duration time.Duration) {
+	walkFilesScanned.Add(float64(stats.FilesScanned))
+	walkDirsSkipped.Add(float64(stats.DirsSkipped))
+	walkDuration.WithLabelValues(source).Observe(duration.Seconds())
+}
+
+func ProviderError(kind string, operation string) {
+	providerErrors.WithLabelValues(kind, operation).Inc()
+}
+
+func ConfigReload(err error) {
+	if err != nil {
+		configReloads.WithLabelValues("rejected").Inc()
+		return
+	}
+	configReloads.WithLabelValues("success").Inc()
+}
+
+func StaticRequest(kind string) {
+	staticRequests.WithLabelValues(kind).Inc()
+}
@@ -0,0 +1,282 @@
+package middleware
+
+import (
+	"backend/config"
+	"backend/handler"
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// トークンを保持するCookie名
+const authCookieName = "repo_wise_token"
+
+type authMiddleware struct {
+	enabled bool
+	// host はサーバーの待ち受けアドレス
+	host           string
+	tokens         []config.APIToken
+	allowedOrigins map[string]bool
+}
+
+var _ handler.Middleware = (*authMiddleware)(nil)
+var _ handler.HTTPMiddleware = (*authMiddleware)(nil)
+var _ handler.ProtectedHTTPMiddleware = (*authMiddleware)(nil)
+
+// NewAuth はトークン認証と更新系リクエストのOriginチェックを行うミドルウェアを返す
+// hostはサーバーの待ち受けアドレスで、認証の要否の判定に使用する
+func NewAuth(authConfig *config.AuthConfig, host string) *authMiddleware {
+	tokens := append([]config.APIToken{{
+		User:  config.LaunchTokenUser,
+		Token: authConfig.LaunchToken,
+	}}, authConfig.APITokens...)
+
+	allowedOrigins := make(map[string]bool, len(authConfig.AllowedOrigins))
+	for _, origin := range authConfig.AllowedOrigins {
+		allowedOrigins[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	return &authMiddleware{
+		enabled:        authConfig.Enabled(host),
+		host:           host,
+		tokens:         tokens,
+		allowedOrigins: allowedOrigins,
+	}
+}
+
+func (m *authMiddleware) Use(ctx huma.Context, next func(huma.Context)) {
+	if !m.hostAllowed(ctx.Host()) {
+		writeProblem(ctx, http.StatusForbidden, "Host is not allowed")
+		return
+	}
+
+	// 認証の有無に関わらず、他サイトからの更新系リクエストは拒否する
+	if isMutatingMethod(ctx.Method()) && !m.originAllowed(ctx) {
+		writeProblem(ctx, http.StatusForbidden, "Cross-origin request is not allowed")
+		return
+	}
+
+	if !m.enabled || handler.IsPublicOperation(ctx.Operation()) {
+		next(ctx)
+		return
+	}
+
+	// ブラウザで ?token= 付きのURLを開いた場合はCookieに保存して以降のリクエストで使う
+	if token := ctx.Query("token"); token != "" {
+		user, ok := m.authenticate(token)
+		if !ok {
+			writeProblem(ctx, http.StatusUnauthorized, "Invalid access token")
+			return
+		}
+		ctx.AppendHeader("Set-Cookie", newAuthCookie(token, ctx.TLS() != nil).String())
+		next(huma.WithContext(ctx, config.WithUser(ctx.Context(), user)))
+		return
+	}
+
+	user, ok := m.authenticate(requestToken(ctx))
+	if !ok {
+		ctx.SetHeader("WWW-Authenticate", `Bearer realm="repo-wise"`)
+		writeProblem(ctx, http.StatusUnauthorized, "Missing or invalid access token")
+		return
+	}
+
+	next(huma.WithContext(ctx, config.WithUser(ctx.Context(), user)))
+}
+
+// Wrap はフロントエンドを ?token= 付きのURLで開いた場合にトークンをCookieに保存する
+// フロントエンド自体は認証なしで配信し、APIの呼び出しで認証する
+func (m *authMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.hostAllowed(r.Host) {
+			http.Error(w, "Host is not allowed", http.StatusForbidden)
+			return
+		}
+
+		query := r.URL.Query()
+		token := query.Get("token")
+		if !m.enabled || token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, ok := m.authenticate(token); !ok {
+			http.Error(w, "Invalid access token", http.StatusUnauthorized)
+			return
+		}
+
+		http.SetCookie(w, newAuthCookie(token, r.TLS != nil))
+
+		// トークンがURLに残らないようにリダイレクトする
+		query.Del("token")
+		redirect := *r.URL
+		redirect.RawQuery = query.Encode()
+		http.Redirect(w, r, redirect.RequestURI(), http.StatusFound)
+	})
+}
+
+// Protect はAPI以外で認証が必要なエンドポイント（WebDAVなど）に認証を適用する
+// ファイルマネージャーなどのクライアントのために、パスワードをトークンとするBasic認証も受け付ける
+func (m *authMiddleware) Protect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.hostAllowed(r.Host) {
+			http.Error(w, "Host is not allowed", http.StatusForbidden)
+			return
+		}
+		if isMutatingMethod(r.Method) && !m.originAllowedFor(r.Header.Get("Origin"), r.Header.Get("Referer"), r.Header.Get("Sec-Fetch-Site"), r.Host) {
+			http.Error(w, "Cross-origin request is not allowed", http.StatusForbidden)
+			return
+		}
+
+		if !m.enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := requestTokenFrom(r.Header.Get("Authorization"), r.Header.Get("Cookie"))
+		if _, password, ok := r.BasicAuth(); ok {
+			token = password
+		}
+		user, ok := m.authenticate(token)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="repo-wise", charset="UTF-8"`)
+			http.Error(w, "Missing or invalid access token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(config.WithUser(r.Context(), user)))
+	})
+}
+
+func newAuthCookie(token string, secure bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     authCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Secure:   secure,
+	}
+}
+
+// authenticate はトークンに対応するユーザー名を返す
+func (m *authMiddleware) authenticate(token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	user := ""
+	for _, t := range m.tokens {
+		if t.Token != "" && subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+			user = t.User
+		}
+	}
+	return user, user != ""
+}
+
+// hostAllowed は認証なしで動作する場合にHostヘッダーが待ち受けアドレスかループバックの名前かを判定する
+// DNSリバインディングで他サイトのページから読み書きされないように、メソッドに関わらず全てのリクエストで確認する
+// 全てのアドレスで待ち受ける場合は、名前解決を経ないIPアドレスの指定も許可する
+func (m *authMiddleware) hostAllowed(requestHost string) bool {
+	if m.enabled {
+		return true
+	}
+	name := hostname(requestHost)
+	if config.IsLoopbackHost(name) || strings.EqualFold(name, hostname(m.host)) {
+		return true
+	}
+	if bind := net.ParseIP(hostname(m.host)); (m.host == "" || bind != nil && bind.IsUnspecified()) && net.ParseIP(name) != nil {
+		return true
+	}
+	for origin := range m.allowedOrigins {
+		if u, err := url.Parse(origin); err == nil && u.Host != "" && strings.EqualFold(u.Host, requestHost) {
+			return true
+		}
+	}
+	return false
+}
+
+// originAllowed はOrigin（無ければReferer）が同一オリジンか許可済みかを判定する
+// Originを送らないCLIなどのクライアントは対象外とする
+func (m *authMiddleware) originAllowed(ctx huma.Context) bool {
+	return m.originAllowedFor(ctx.Header("Origin"), ctx.Header("Referer"), ctx.Header("Sec-Fetch-Site"), ctx.Host())
+}
+
+func (m *authMiddleware) originAllowedFor(origin string, referer string, fetchSite string, host string) bool {
+	if origin == "" {
+		if referer != "" {
+			if u, err := url.Parse(referer); err == nil {
+				origin = u.Scheme + "://" + u.Host
+			}
+		}
+	}
+	if origin == "" {
+		return fetchSite != "cross-site"
+	}
+	if m.allowedOrigins[origin] {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if u.Host == host {
+		return true
+	}
+
+	// 開発時のVite（別ポート）からのリクエストはループバック同士なら許可する
+	return config.IsLoopbackHost(hostname(u.Host)) && config.IsLoopbackHost(hostname(host))
+}
+
+func requestToken(ctx huma.Context) string {
+	return requestTokenFrom(ctx.Header("Authorization"), ctx.Header("Cookie"))
+}
+
+func requestTokenFrom(auth string, cookie string) string {
+	if auth != "" {
+		if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	if cookie != "" {
+		cookies, err := http.ParseCookie(cookie)
+		if err == nil {
+			for _, c := range cookies {
+				if c.Name == authCookieName {
+					return c.Value
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+func hostname(hostport string) string {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return strings.Trim(hostport, "[]")
+	}
+	return host
+}
+
+func writeProblem(ctx huma.Context, status int, detail string) {
+	ctx.SetHeader("Content-Type", "application/problem+json")
+	ctx.SetStatus(status)
+	_ = json.NewEncoder(ctx.BodyWriter()).Encode(&huma.ErrorModel{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+}
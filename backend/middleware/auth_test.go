@@ -0,0 +1,384 @@
+package middleware
+
+import (
+	"backend/config"
+	"backend/handler"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+)
+
+const testToken = "secret-token"
+
+func newTestAuth(mode config.AuthMode, host string) *authMiddleware {
+	return NewAuth(&config.AuthConfig{
+		Mode:           mode,
+		LaunchToken:    testToken,
+		APITokens:      []config.APIToken{{User: "alice", Token: "alice-token"}},
+		AllowedOrigins: []string{"https://docs.example.com/"},
+	}, host)
+}
+
+type userOutput struct {
+	Body struct {
+		User string `json:"user"`
+	}
+}
+
+// newTestAPI はミドルウェアを適用したAPIを返す
+// /itemsは認証が必要で、/publicは認証なしで呼び出せる
+func newTestAPI(t *testing.T, m *authMiddleware) humatest.TestAPI {
+	_, api := humatest.New(t)
+	api.UseMiddleware(m.Use)
+	handle := func(ctx context.Context, _ *struct{}) (*userOutput, error) {
+		out := &userOutput{}
+		out.Body.User, _ = config.UserFromContext(ctx)
+		return out, nil
+	}
+	huma.Get(api, "/items", handle)
+	huma.Post(api, "/items", handle)
+	huma.Register(api, huma.Operation{
+		Method:   http.MethodGet,
+		Path:     "/public",
+		Metadata: map[string]any{handler.PublicOperationKey: true},
+	}, handle)
+	return api
+}
+
+func TestAuthUse(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   config.AuthMode
+		bind   string
+		method string
+		path   string
+		args   []any
+		want   int
+		user   string
+	}{
+		{
+			name:   "loopback without auth",
+			mode:   config.AuthAuto,
+			bind:   "localhost",
+			method: http.MethodGet,
+			path:   "/items",
+			args:   []any{"Host: localhost"},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "loopback address without auth",
+			mode:   config.AuthAuto,
+			bind:   "localhost",
+			method: http.MethodGet,
+			path:   "/items",
+			args:   []any{"Host: 127.0.0.1"},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "rebound host is rejected for reads",
+			mode:   config.AuthAuto,
+			bind:   "localhost",
+			method: http.MethodGet,
+			path:   "/items",
+			args:   []any{"Host: attacker.example"},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "rebound host is rejected even with a matching origin",
+			mode:   config.AuthAuto,
+			bind:   "localhost",
+			method: http.MethodPost,
+			path:   "/items",
+			args:   []any{"Host: attacker.example", "Origin: http://attacker.example"},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "rebound host is rejected for public operations",
+			mode:   config.AuthDisabled,
+			bind:   "localhost",
+			method: http.MethodGet,
+			path:   "/public",
+			args:   []any{"Host: attacker.example"},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "bind host is allowed when auth is disabled",
+			mode:   config.AuthDisabled,
+			bind:   "notes.lan",
+			method: http.MethodGet,
+			path:   "/items",
+			args:   []any{"Host: notes.lan"},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "ip address is allowed when listening on all addresses",
+			mode:   config.AuthDisabled,
+			bind:   "0.0.0.0",
+			method: http.MethodGet,
+			path:   "/items",
+			args:   []any{"Host: 192.168.1.10"},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "host name is rejected when listening on all addresses",
+			mode:   config.AuthDisabled,
+			bind:   "0.0.0.0",
+			method: http.MethodGet,
+			path:   "/items",
+			args:   []any{"Host: attacker.example"},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "allowed origin host is allowed",
+			mode:   config.AuthDisabled,
+			bind:   "localhost",
+			method: http.MethodGet,
+			path:   "/items",
+			args:   []any{"Host: docs.example.com"},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "cross-origin write is rejected",
+			mode:   config.AuthAuto,
+			bind:   "localhost",
+			method: http.MethodPost,
+			path:   "/items",
+			args:   []any{"Host: localhost", "Origin: https://attacker.example"},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "cross-site write without origin is rejected",
+			mode:   config.AuthAuto,
+			bind:   "localhost",
+			method: http.MethodPost,
+			path:   "/items",
+			args:   []any{"Host: localhost", "Sec-Fetch-Site: cross-site"},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "write from the dev server on another loopback port is allowed",
+			mode:   config.AuthAuto,
+			bind:   "localhost",
+			method: http.MethodPost,
+			path:   "/items",
+			args:   []any{"Host: localhost", "Origin: http://localhost:5173"},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "write from an allowed origin is allowed",
+			mode:   config.AuthRequired,
+			bind:   "0.0.0.0",
+			method: http.MethodPost,
+			path:   "/items",
+			args:   []any{"Host: notes.example.com", "Origin: https://docs.example.com", "Authorization: Bearer " + testToken},
+			want:   http.StatusOK,
+			user:   config.LaunchTokenUser,
+		},
+		{
+			name:   "missing token",
+			mode:   config.AuthAuto,
+			bind:   "0.0.0.0",
+			method: http.MethodGet,
+			path:   "/items",
+			args:   []any{"Host: notes.example.com"},
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "invalid token",
+			mode:   config.AuthRequired,
+			bind:   "localhost",
+			method: http.MethodGet,
+			path:   "/items",
+			args:   []any{"Host: localhost", "Authorization: Bearer wrong"},
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "api token from the cookie",
+			mode:   config.AuthRequired,
+			bind:   "localhost",
+			method: http.MethodGet,
+			path:   "/items",
+			args:   []any{"Host: localhost", "Cookie: " + authCookieName + "=alice-token"},
+			want:   http.StatusOK,
+			user:   "alice",
+		},
+		{
+			name:   "api token from the query",
+			mode:   config.AuthRequired,
+			bind:   "localhost",
+			method: http.MethodGet,
+			path:   "/items?token=alice-token",
+			args:   []any{"Host: localhost"},
+			want:   http.StatusOK,
+			user:   "alice",
+		},
+		{
+			name:   "public operation without token",
+			mode:   config.AuthRequired,
+			bind:   "0.0.0.0",
+			method: http.MethodGet,
+			path:   "/public",
+			args:   []any{"Host: notes.example.com"},
+			want:   http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t, newTestAuth(tt.mode, tt.bind))
+			resp := api.Do(tt.method, tt.path, tt.args...)
+			if resp.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", resp.Code, tt.want, resp.Body)
+			}
+			if tt.user != "" && !strings.Contains(resp.Body.String(), `"user":"`+tt.user+`"`) {
+				t.Errorf("body = %s, want user %s", resp.Body, tt.user)
+			}
+		})
+	}
+}
+
+func TestAuthWrap(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("rebound host is rejected", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "http://attacker.example:8080/", nil)
+		newTestAuth(config.AuthAuto, "localhost").Wrap(next).ServeHTTP(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+		}
+	})
+
+	t.Run("token in the url is moved to a cookie", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "http://notes.example.com/browse?token="+testToken+"&q=1", nil)
+		newTestAuth(config.AuthRequired, "0.0.0.0").Wrap(next).ServeHTTP(w, r)
+		if w.Code != http.StatusFound {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusFound)
+		}
+		if got := w.Header().Get("Location"); got != "/browse?q=1" {
+			t.Errorf("location = %q, want %q", got, "/browse?q=1")
+		}
+		if got := w.Header().Get("Set-Cookie"); !strings.HasPrefix(got, authCookieName+"="+testToken) || !strings.Contains(got, "HttpOnly") {
+			t.Errorf("cookie = %q", got)
+		}
+	})
+
+	t.Run("invalid token in the url", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "http://notes.example.com/?token=wrong", nil)
+		newTestAuth(config.AuthRequired, "0.0.0.0").Wrap(next).ServeHTTP(w, r)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	})
+}
+
+func TestAuthProtect(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ := config.UserFromContext(r.Context())
+		w.Write([]byte(user))
+	})
+
+	tests := []struct {
+		name   string
+		mode   config.AuthMode
+		bind   string
+		method string
+		url    string
+		header map[string]string
+		basic  string
+		want   int
+		user   string
+	}{
+		{
+			name:   "loopback without auth",
+			mode:   config.AuthAuto,
+			bind:   "localhost",
+			method: "PROPFIND",
+			url:    "http://localhost:8080/dav/",
+			want:   http.StatusOK,
+		},
+		{
+			name:   "ipv6 loopback without auth",
+			mode:   config.AuthAuto,
+			bind:   "127.0.0.1",
+			method: http.MethodGet,
+			url:    "http://[::1]:8080/dav/notes.md",
+			want:   http.StatusOK,
+		},
+		{
+			name:   "rebound host is rejected",
+			mode:   config.AuthAuto,
+			bind:   "localhost",
+			method: http.MethodGet,
+			url:    "http://attacker.example:8080/dav/notes.md",
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "cross-origin write is rejected",
+			mode:   config.AuthAuto,
+			bind:   "localhost",
+			method: http.MethodPut,
+			url:    "http://localhost:8080/dav/notes.md",
+			header: map[string]string{"Origin": "https://attacker.example"},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "missing credentials",
+			mode:   config.AuthRequired,
+			bind:   "0.0.0.0",
+			method: "PROPFIND",
+			url:    "http://notes.example.com/dav/",
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "basic auth with the token as password",
+			mode:   config.AuthRequired,
+			bind:   "0.0.0.0",
+			method: "PROPFIND",
+			url:    "http://notes.example.com/dav/",
+			basic:  "alice-token",
+			want:   http.StatusOK,
+			user:   "alice",
+		},
+		{
+			name:   "bearer token",
+			mode:   config.AuthRequired,
+			bind:   "0.0.0.0",
+			method: http.MethodGet,
+			url:    "http://notes.example.com/metrics",
+			header: map[string]string{"Authorization": "Bearer " + testToken},
+			want:   http.StatusOK,
+			user:   config.LaunchTokenUser,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, tt.url, nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			if tt.basic != "" {
+				r.SetBasicAuth("ignored", tt.basic)
+			}
+			newTestAuth(tt.mode, tt.bind).Protect(next).ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Basic ") {
+				t.Errorf("WWW-Authenticate = %q", w.Header().Get("WWW-Authenticate"))
+			}
+			if got := w.Body.String(); tt.user != "" && got != tt.user {
+				t.Errorf("user = %q, want %q", got, tt.user)
+			}
+		})
+	}
+}
@@ -2,25 +2,155 @@ package middleware
 
 import (
 	"backend/handler"
-	"log"
+	"backend/logging"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 )
 
-type loggerMiddleware struct{}
+// リクエストIDのヘッダー名
+const requestIDHeader = "X-Request-ID"
+
+// クライアントから受け取ったリクエストIDとして許可する形式
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+type loggerMiddleware struct {
+	logger *slog.Logger
+}
 
 var _ handler.Middleware = (*loggerMiddleware)(nil)
+var _ handler.HTTPMiddleware = (*loggerMiddleware)(nil)
 
-func NewLogger() *loggerMiddleware {
-	return &loggerMiddleware{}
+func NewLogger(logger *slog.Logger) *loggerMiddleware {
+	return &loggerMiddleware{logger: logger}
 }
 
 func (m *loggerMiddleware) Use(ctx huma.Context, next func(huma.Context)) {
 	start := time.Now()
-	log.Printf("Started %s", ctx.Method())
 
-	log.Println(ctx.URL().Path)
+	requestID := requestIDFrom(ctx.Header(requestIDHeader))
+	ctx.SetHeader(requestIDHeader, requestID)
+
+	body := &countingWriter{w: ctx.BodyWriter()}
+	ctx = &countingContext{
+		humaContext: huma.WithContext(ctx, logging.WithRequest(ctx.Context(), m.logger, requestID)),
+		body:        body,
+	}
+
 	next(ctx)
-	log.Printf("Completed %s in %v", ctx.Operation().Path, time.Since(start))
+
+	attrs := []slog.Attr{
+		slog.String("request_id", requestID),
+		slog.String("method", ctx.Method()),
+		slog.String("route", ctx.Operation().Path),
+		slog.String("path", ctx.URL().Path),
+		slog.Int("status", ctx.Status()),
+		slog.Int64("bytes", body.n),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if params := pathParams(ctx); len(params) > 0 {
+		attrs = append(attrs, slog.Any("params", params))
+	}
+	attrs = append(attrs, logging.Attrs(ctx.Context())...)
+
+	m.logger.LogAttrs(ctx.Context(), levelFor(ctx.Status()), "request", attrs...)
+}
+
+// Wrap はAPI以外のリクエスト（フロントエンドの配信など）のアクセスログを出力する
+func (m *loggerMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := requestIDFrom(r.Header.Get(requestIDHeader))
+		w.Header().Set(requestIDHeader, requestID)
+
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r.WithContext(logging.WithRequest(r.Context(), m.logger, requestID)))
+
+		m.logger.LogAttrs(r.Context(), levelFor(rw.status), "request",
+			slog.String("request_id", requestID),
+			slog.String("method", r.Method),
+			slog.String("route", "static"),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rw.status),
+			slog.Int64("bytes", rw.n),
+			slog.Duration("duration", time.Since(start)),
+		)
+	})
+}
+
+func requestIDFrom(header string) string {
+	if requestIDPattern.MatchString(header) {
+		return header
+	}
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func pathParams(ctx huma.Context) map[string]string {
+	params := map[string]string{}
+	for _, p := range ctx.Operation().Parameters {
+		if p.In == "path" {
+			params[p.Name] = ctx.Param(p.Name)
+		}
+	}
+	return params
+}
+
+func levelFor(status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+type humaContext = huma.Context
+
+// countingContext はレスポンスのバイト数を数えるためにBodyWriterを差し替える
+type countingContext struct {
+	humaContext
+	body *countingWriter
+}
+
+func (c *countingContext) BodyWriter() io.Writer {
+	return c.body
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	n      int64
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(p)
+	r.n += int64(n)
+	return n, err
 }
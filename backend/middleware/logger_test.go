@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"backend/logging"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+)
+
+type itemOutput struct {
+	Body struct {
+		RequestID string `json:"request_id"`
+	}
+}
+
+// newTestLogger はJSONで出力するロガーと出力先を返す
+func newTestLogger() (*loggerMiddleware, *bytes.Buffer) {
+	logs := &bytes.Buffer{}
+	return NewLogger(slog.New(slog.NewJSONHandler(logs, nil))), logs
+}
+
+// requestLog は最後に出力されたアクセスログを返す
+func requestLog(t *testing.T, logs *bytes.Buffer) map[string]any {
+	t.Helper()
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &entry); err != nil {
+		t.Fatalf("log = %q: %v", logs.String(), err)
+	}
+	return entry
+}
+
+func TestLoggerUse(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		requestID string
+		wantID    string
+		want      int
+		wantLevel string
+	}{
+		{"request ID from the client", "/items/42", "abc-123", "abc-123", http.StatusOK, "INFO"},
+		{"invalid request ID is replaced", "/items/42", "bad id\n", "", http.StatusOK, "INFO"},
+		{"client error", "/items/missing", "", "", http.StatusNotFound, "WARN"},
+		{"server error", "/items/broken", "", "", http.StatusInternalServerError, "ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, logs := newTestLogger()
+			_, api := humatest.New(t)
+			api.UseMiddleware(m.Use)
+			huma.Get(api, "/items/{id}", func(ctx context.Context, input *struct {
+				ID string `path:"id"`
+			}) (*itemOutput, error) {
+				switch input.ID {
+				case "missing":
+					return nil, huma.Error404NotFound("not found")
+				case "broken":
+					return nil, huma.Error500InternalServerError("broken")
+				}
+				out := &itemOutput{}
+				out.Body.RequestID = logging.RequestID(ctx)
+				return out, nil
+			})
+
+			var args []any
+			if tt.requestID != "" {
+				args = append(args, requestIDHeader+": "+tt.requestID)
+			}
+			resp := api.Get(tt.path, args...)
+			if resp.Code != tt.want {
+				t.Fatalf("status = %d, want %d", resp.Code, tt.want)
+			}
+
+			requestID := resp.Header().Get(requestIDHeader)
+			if tt.wantID != "" && requestID != tt.wantID {
+				t.Errorf("request ID = %q, want %q", requestID, tt.wantID)
+			}
+			if !requestIDPattern.MatchString(requestID) {
+				t.Errorf("request ID = %q is not valid", requestID)
+			}
+
+			entry := requestLog(t, logs)
+			if entry["request_id"] != requestID || entry["level"] != tt.wantLevel ||
+				entry["route"] != "/items/{id}" || entry["path"] != tt.path || entry["status"] != float64(tt.want) {
+				t.Errorf("log = %v", entry)
+			}
+			if params, _ := entry["params"].(map[string]any); params["id"] != strings.TrimPrefix(tt.path, "/items/") {
+				t.Errorf("params = %v", entry["params"])
+			}
+			if size, _ := entry["bytes"].(float64); size != float64(resp.Body.Len()) {
+				t.Errorf("bytes = %v, want %d", entry["bytes"], resp.Body.Len())
+			}
+		})
+	}
+}
+
+func TestLoggerWrap(t *testing.T) {
+	m, logs := newTestLogger()
+	var handlerRequestID string
+	h := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerRequestID = logging.RequestID(r.Context())
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/assets/app.js", nil)
+	req.Header.Set(requestIDHeader, "static-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(requestIDHeader); got != "static-1" || handlerRequestID != "static-1" {
+		t.Errorf("request ID = %q, in handler %q, want static-1", got, handlerRequestID)
+	}
+	entry := requestLog(t, logs)
+	if entry["route"] != "static" || entry["status"] != float64(http.StatusNotFound) || entry["bytes"] != float64(len("not found")) || entry["level"] != "WARN" {
+		t.Errorf("log = %v", entry)
+	}
+}
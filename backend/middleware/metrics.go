@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"backend/handler"
+	"backend/metrics"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type metricsMiddleware struct{}
+
+var _ handler.Middleware = (*metricsMiddleware)(nil)
+
+// NewMetrics はhumaのオペレーションごとのリクエスト数とレイテンシを記録するミドルウェアを返す
+func NewMetrics() *metricsMiddleware {
+	return &metricsMiddleware{}
+}
+
+func (m *metricsMiddleware) Use(ctx huma.Context, next func(huma.Context)) {
+	start := time.Now()
+	next(ctx)
+	metrics.ObserveRequest(ctx.Operation().OperationID, ctx.Method(), ctx.Status(), time.Since(start))
+}
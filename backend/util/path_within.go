@@ -0,0 +1,51 @@
+package util
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// PathWithin はpathがroots（いずれか）の配下にあるかを返す
+// シンボリックリンクは解決してから比較する
+func PathWithin(path string, roots []string) bool {
+	resolved := resolvePath(path)
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		r := resolvePath(root)
+		if resolved == r {
+			return true
+		}
+		prefix := r
+		if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+			prefix += string(filepath.Separator)
+		}
+		if strings.HasPrefix(resolved, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath は存在する最も深い祖先のシンボリックリンクを解決した絶対パスを返す
+func resolvePath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+
+	rest := ""
+	current := abs
+	for {
+		if resolved, err := filepath.EvalSymlinks(current); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return abs
+		}
+		rest = filepath.Join(filepath.Base(current), rest)
+		current = parent
+	}
+}
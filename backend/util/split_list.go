@@ -0,0 +1,14 @@
+package util
+
+import "strings"
+
+// SplitList はカンマ区切りの文字列を分割し、空の要素を除いて返す
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -1,6 +1,6 @@
 module.exports = {
     'backend-api': {
-        input: 'http://localhost:8070/openapi.yaml',
+        input: 'http://localhost:8070/api/openapi.yaml',
         output: {
             mode: 'split',
             target: './src/api',
@@ -8,6 +8,10 @@
 export interface FileInfo {
   /** Whether this is a directory */
   is_dir: boolean;
+  /** Last modification time (not set when unknown) */
+  mod_time?: string;
   /** File or directory name */
   name: string;
+  /** Size of the file in bytes (not set for directories or when unknown) */
+  size?: number;
 }
@@ -14,4 +14,8 @@ path?: string;
  * Kind of document source (e.g., 'local', 'github')
  */
 kind?: string;
+/**
+ * Format the Markdown before saving
+ */
+format?: boolean;
 };
@@ -8,6 +8,8 @@
 export interface UpdateDocumentContentOutputBody {
   /** A URL to the JSON Schema for this object. */
   readonly $schema?: string;
+  /** Saved content when it was formatted before saving */
+  content?: string;
   /** Success or error message */
   message: string;
   /** Document path */
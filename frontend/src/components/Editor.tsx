@@ -38,7 +38,9 @@ export function Editor({ activeFile, onFileDeleted }: EditorProps) {
 
 	const { trigger: updateDocumentContent, isMutating: isSaving } =
 		usePutDocumentContent(
-			activeFile ? { path: activeFile, kind: "local" } : undefined,
+			activeFile
+				? { path: activeFile, kind: "local", format: true }
+				: undefined,
 		);
 
 	useEffect(() => {
@@ -96,48 +98,25 @@ export function Editor({ activeFile, onFileDeleted }: EditorProps) {
 		setShowDeleteDialog(false);
 	};
 
-	const formatContent = useCallback((content: string): string => {
-		// HTMLエンティティをデコード
-		let formatted = content
-			.replace(/&#x20;/g, " ") // スペース
-			.replace(/&#xa0;/g, "\u00A0") // ノンブレークスペース
-			.replace(/&nbsp;/g, "\u00A0") // ノンブレークスペース
-			.replace(/&amp;/g, "&") // アンパサンド
-			.replace(/&lt;/g, "<") // 小なり
-			.replace(/&gt;/g, ">") // 大なり
-			.replace(/&quot;/g, '"') // ダブルクォート
-			.replace(/&#x27;/g, "'"); // シングルクォート
-
-		// 連続する空行を最大2つまでに制限
-		formatted = formatted.replace(/\n{3,}/g, "\n\n");
-
-		// 行の末尾の不要な空白を削除
-		formatted = formatted.replace(/[ \t\u00A0]+$/gm, "");
-
-		// ファイルの先頭と末尾の不要な改行を削除
-		formatted = formatted.replace(/^\n+/, "").replace(/\n+$/, "");
-
-		// 末尾に改行を1つ確保（Markdownファイルの標準として）
-		formatted = `${formatted}\n`;
-
-		return formatted;
-	}, []);
-
 	const handleSave = useCallback(async () => {
 		if (!activeFile || !isDirty) return;
 
 		try {
-			const contentToSave = formatContent(localContent);
-
-			await updateDocumentContent({
-				content: contentToSave,
+			// 書式はサーバーで整え、整えた内容をエディターに反映する
+			const response = await updateDocumentContent({
+				content: localContent,
 			});
+			const savedContent = response.data.content;
+			if (savedContent !== undefined && savedContent !== localContent) {
+				setLocalContent(savedContent);
+				mdxEditorRef.current?.setMarkdown(savedContent);
+			}
 			setIsDirty(false);
 		} catch (error) {
 			console.error("Failed to save document:", error);
 			alert("Failed to save document");
 		}
-	}, [activeFile, isDirty, localContent, updateDocumentContent, formatContent]);
+	}, [activeFile, isDirty, localContent, updateDocumentContent]);
 
 	// Ctrl/Cmd+S キーボードショートカット
 	useEffect(() => {
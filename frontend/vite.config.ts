@@ -22,8 +22,12 @@ export default defineConfig({
       '/api': {
         target: 'http://localhost:8070',
         changeOrigin: true,
-        rewrite: (path) => path.replace(/^\/api/, ''),
       },
     },
   },
+  // The backend embeds the build output and serves it as a single binary
+  build: {
+    outDir: resolve(__dirname, '../backend/handler/static/dist'),
+    emptyOutDir: true,
+  },
 })